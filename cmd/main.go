@@ -5,15 +5,15 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
 	"github.com/labi-le/chiasma/internal/config"
 	"github.com/labi-le/chiasma/internal/service"
-	"github.com/labi-le/chiasma/pkg/api/local"
-	"github.com/labi-le/chiasma/pkg/api/nasa"
+	"github.com/labi-le/chiasma/pkg/api/external"
+	"github.com/labi-le/chiasma/pkg/api/multi"
 	"github.com/labi-le/chiasma/pkg/api/searcher"
-	"github.com/labi-le/chiasma/pkg/api/unsplash"
 	"github.com/labi-le/chiasma/pkg/browser"
 	"github.com/labi-le/chiasma/pkg/wallpaper"
 	"github.com/rs/zerolog"
@@ -22,19 +22,26 @@ import (
 func main() {
 	cfg, err := config.Parse()
 	if err != nil {
-		panic(err)
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
 	log := initLogger(cfg.Verbose)
 
-	srchr, err := NewSearcher(log, cfg.APIName, cfg.SaveDir)
+	srchr, err := NewSearcher(log, cfg)
 	if err != nil {
 		log.Fatal().Err(err).Msg("failed to init api")
 	}
 
 	var historyProvider service.QuerySource
-	if cfg.SearchPhrase == "" {
-		hp, err := browser.NewHistoryProvider(cfg.BrowserName, cfg.HistoryPath)
+	if cfg.SearchPhrase == "" && !searcher.IgnoresPhrase(srchr) {
+		hp, err := browser.NewHistoryProvider(browser.Options{
+			Browser:     cfg.BrowserName,
+			HistoryPath: cfg.HistoryPath,
+			Profile:     cfg.BrowserProfile,
+			Engines:     cfg.SearchEngines,
+			OnlyEngines: cfg.HistoryEngines,
+		})
 		if err != nil {
 			log.Warn().Err(err).Msg("failed to init browser history, fallback to random or manual phrase might fail")
 		} else {
@@ -69,12 +76,21 @@ func main() {
 		Setter:  tool,
 	}
 
+	if cfg.Remember > 0 {
+		seen, err := service.LoadSeen(filepath.Join(cfg.SaveDir, service.SeenFile), cfg.Remember)
+		if err != nil {
+			log.Warn().Err(err).Msg("failed to load seen list, starting a new one")
+		}
+		svc.Seen = seen
+	}
+
 	params := service.UpdateParams{
 		Phrase:     cfg.SearchPhrase,
 		Resolution: resolution,
 		SaveDir:    cfg.SaveDir,
 		OutputID:   cfg.OutputMonitor.ID,
 		RetryCount: 5,
+		Blocklist:  cfg.Blocklist,
 	}
 
 	ctx, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
@@ -138,15 +154,52 @@ func initLogger(verbose bool) zerolog.Logger {
 		Logger()
 }
 
-func NewSearcher(log zerolog.Logger, name string, dir string) (searcher.Searcher, error) {
-	switch name {
-	case unsplash.Name:
-		return unsplash.NewUnsplash(log), nil
-	case nasa.Name:
-		return nasa.NewNasa(log), nil
-	case local.Name:
-		return local.NewLocal(log, dir), nil
-	default:
-		return nil, searcher.ErrUnknownSearcher
+func NewSearcher(log zerolog.Logger, cfg config.Config) (searcher.Searcher, error) {
+	if len(cfg.Mix) > 0 {
+		return newMix(log, cfg)
+	}
+
+	names := cfg.Names()
+	if len(names) == 1 {
+		return newSearcher(log, names[0], cfg)
+	}
+
+	sources := make([]multi.Source, 0, len(names))
+	for _, name := range names {
+		s, err := newSearcher(log, name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sources = append(sources, multi.Source{Name: name, Searcher: s})
+	}
+
+	return multi.NewFallback(log, cfg.APITimeout, sources...), nil
+}
+
+func newMix(log zerolog.Logger, cfg config.Config) (searcher.Searcher, error) {
+	sources := make([]multi.Weighted, 0, len(cfg.Mix))
+	for name, weight := range cfg.Mix {
+		s, err := newSearcher(log, name, cfg)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		sources = append(sources, multi.Weighted{
+			Source: multi.Source{Name: name, Searcher: s},
+			Weight: weight,
+			Phrase: cfg.MixPhrases[name],
+		})
 	}
+
+	return multi.NewMix(log, cfg.APITimeout, cfg.MixMaxRepeat, sources...)
+}
+
+// newSearcher builds a registered source, unknown names are looked up as external executables
+func newSearcher(log zerolog.Logger, name string, cfg config.Config) (searcher.Searcher, error) {
+	if _, ok := searcher.Lookup(name); !ok {
+		if bin, ok := external.Lookup(name); ok {
+			return external.NewExternal(log, name, bin), nil
+		}
+	}
+
+	return cfg.Sources.New(log, name, searcher.Env{SaveDir: cfg.SaveDir, Paging: cfg.Paging})
 }
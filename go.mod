@@ -7,6 +7,8 @@ require (
 	github.com/rs/zerolog v1.34.0
 	github.com/spf13/pflag v1.0.10
 	github.com/vcraescu/go-xrandr v0.0.0-20250120044713-67143ce1bea9
+	golang.org/x/image v0.25.0
+	golang.org/x/text v0.23.0
 	modernc.org/sqlite v1.44.3
 )
 
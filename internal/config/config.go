@@ -1,9 +1,15 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
 	"time"
 
+	"github.com/labi-le/chiasma/pkg/api/external"
+	"github.com/labi-le/chiasma/pkg/api/multi"
 	"github.com/labi-le/chiasma/pkg/api/nasa"
 	"github.com/labi-le/chiasma/pkg/api/searcher"
 	"github.com/labi-le/chiasma/pkg/browser"
@@ -13,32 +19,121 @@ import (
 type Config struct {
 	BrowserName    string
 	HistoryPath    string
+	BrowserProfile string
+	SearchEngines  map[string]string
+	HistoryEngines []string
 	Resolution     searcher.Resolution
 	OutputMonitor  searcher.Monitor
 	ToolName       string
 	APIName        string
+	APITimeout     time.Duration
+	Mix            map[string]int
+	MixPhrases     map[string]string
+	MixMaxRepeat   int
 	SaveDir        string
 	SearchPhrase   string
 	Follow         bool
 	FollowDuration time.Duration
 	Verbose        bool
+	Blocklist      []string
+	Remember       int
+	Paging         searcher.PageOptions
+	Sources        *searcher.Sources
 }
 
 func Parse() (Config, error) {
 	var c Config
 	flag.StringVar(&c.BrowserName, "browser", browser.AvailableBrowsers()[0], "browser name")
 	flag.StringVar(&c.HistoryPath, "history-file", "", "path to history file")
+	flag.StringVar(&c.BrowserProfile, "browser-profile", "", "browser profile name or directory, all reads every profile (default the browser's default profile)")
+	flag.StringSliceVar(&c.HistoryEngines, "history-engine", nil, "only use history searches made with these engines (e.g. duckduckgo,kagi)")
+	flag.StringToStringVar(&c.SearchEngines, "search-engine", nil, "extra search engines read from history as name=url with {query} (e.g. searx=https://searx.example.org/search?q={query})")
 	flag.Var(&c.Resolution, "resolution", "target resolution (e.g. 1920x1080)")
 	flag.Var(&c.OutputMonitor, "output", "monitor output (e.g. eDP-1)")
 	flag.StringVar(&c.ToolName, "tool", "", "wallpaper tool")
-	flag.StringVar(&c.APIName, "api", nasa.Name, "image source api")
+	flag.StringVar(&c.APIName, "api", nasa.Name, "image source api, a comma-separated list is tried in order (see sources below)")
+	flag.DurationVar(&c.APITimeout, "api-timeout", 30*time.Second, "time a source may take before falling back to the next one")
+	flag.StringToIntVar(&c.Mix, "mix", nil, "pick a source per update by weight (e.g. wallhaven=60,local=30,nasa=10), overrides --api")
+	flag.StringToStringVar(&c.MixPhrases, "mix-phrase", nil, "per-source phrase overrides for --mix (e.g. nasa=nebula)")
+	flag.IntVar(&c.MixMaxRepeat, "mix-max-repeat", 2, "max times in a row the same --mix source is used, 0 disables the limit")
 	flag.StringVar(&c.SaveDir, "save-dir", os.Getenv("HOME")+"/Pictures/chiasma", "save directory")
 	flag.StringVar(&c.SearchPhrase, "phrase", "", "search phrase")
 	flag.DurationVar(&c.FollowDuration, "interval", time.Hour, "update interval")
 	flag.BoolVar(&c.Follow, "follow", false, "enable periodic updates")
 	flag.BoolVar(&c.Verbose, "verbose", false, "enable verbose logs")
+	flag.StringSliceVar(&c.Blocklist, "blocklist", nil, "skip images whose url, title, author or tags contain any of these terms")
+	flag.IntVar(&c.Remember, "remember", 500, "how many used images are remembered and tried last, 0 disables")
+	flag.IntVar(&c.Paging.Depth, "page-depth", 5, "deepest result page searchers request per phrase, 1 disables paging")
+	flag.StringVar(&c.Paging.Mode, "page-mode", searcher.PageRandom, "how result pages are walked: random or sequential")
+
+	c.Sources = searcher.Bind(flag.CommandLine)
+	flag.Usage = usage
 
 	flag.Parse()
 
+	if err := c.validate(); err != nil {
+		return c, err
+	}
+
 	return c, nil
 }
+
+// Names returns the source names selected by --api or --mix
+func (c Config) Names() []string {
+	if len(c.Mix) > 0 {
+		names := make([]string, 0, len(c.Mix))
+		for name := range c.Mix {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	names := strings.Split(c.APIName, multi.Separator)
+	for i := range names {
+		names[i] = strings.TrimSpace(names[i])
+	}
+	return names
+}
+
+func (c Config) validate() error {
+	for _, name := range c.Names() {
+		if _, ok := searcher.Lookup(name); ok {
+			continue
+		}
+		if _, ok := external.Lookup(name); ok {
+			continue
+		}
+		return searcher.UnknownError(name)
+	}
+	if err := c.Paging.Validate(); err != nil {
+		return err
+	}
+	if _, err := browser.Engines(c.SearchEngines); err != nil {
+		return err
+	}
+	for name := range c.MixPhrases {
+		if _, ok := c.Mix[name]; !ok {
+			return fmt.Errorf("--mix-phrase %q is not a --mix source", name)
+		}
+	}
+	return nil
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	fmt.Fprintf(out, "Usage of %s:\n", os.Args[0])
+	flag.PrintDefaults()
+
+	fmt.Fprintln(out, "\nsources:")
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for _, r := range searcher.Registered() {
+		needs := "offline"
+		if r.NeedsNetwork {
+			needs = "network"
+		}
+		fmt.Fprintf(w, "  %s\t%s\t(%s, %s)\n", r.Name, r.Description, r.Phrase, needs)
+	}
+	fmt.Fprintf(w, "  <name>\tany %s<name> executable in PATH\n", external.Prefix)
+	_ = w.Flush()
+}
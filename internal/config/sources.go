@@ -0,0 +1,16 @@
+package config
+
+// sources register themselves with the searcher registry on import
+import (
+	_ "github.com/labi-le/chiasma/pkg/api/bing"
+	_ "github.com/labi-le/chiasma/pkg/api/feed"
+	_ "github.com/labi-le/chiasma/pkg/api/generate"
+	_ "github.com/labi-le/chiasma/pkg/api/jsonapi"
+	_ "github.com/labi-le/chiasma/pkg/api/local"
+	_ "github.com/labi-le/chiasma/pkg/api/nasa"
+	_ "github.com/labi-le/chiasma/pkg/api/reddit"
+	_ "github.com/labi-le/chiasma/pkg/api/text"
+	_ "github.com/labi-le/chiasma/pkg/api/unsplash"
+	_ "github.com/labi-le/chiasma/pkg/api/wallhaven"
+	_ "github.com/labi-le/chiasma/pkg/api/wikimedia"
+)
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func TestSourcesRegistered(t *testing.T) {
+	for _, name := range []string{
+		"bing", "feed", "generate", "jsonapi", "local", "nasa", "nasa-apod",
+		"reddit", "text", "unsplash", "wallhaven", "wikimedia",
+	} {
+		if _, ok := searcher.Lookup(name); !ok {
+			t.Errorf("source %s is not registered", name)
+		}
+	}
+}
+
+func TestSourcesDeclarePhraseNeed(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("UNSPLASH_ACCESS_KEY", "")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	sources := searcher.Bind(fs)
+	if err := fs.Parse(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	env := searcher.Env{SaveDir: t.TempDir()}
+	for _, r := range searcher.Registered() {
+		s, err := sources.New(zerolog.Nop(), r.Name, env)
+		if err != nil {
+			// sources that cannot run without options have nothing to compare
+			continue
+		}
+		if got := searcher.PhraseNeedOf(s); got != r.Phrase {
+			t.Errorf("%s registered as %q, built with the default options as %q", r.Name, r.Phrase, got)
+		}
+	}
+}
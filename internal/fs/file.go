@@ -2,6 +2,7 @@ package fs
 
 import (
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"strings"
 
 	"github.com/gabriel-vasile/mimetype"
+	"github.com/labi-le/chiasma/pkg/api/searcher"
 )
 
 var (
@@ -45,6 +47,15 @@ func SaveFile(data io.Reader, dir string, tags []string) (string, bool, error) {
 	return gen, false, os.WriteFile(gen, img, 0600)
 }
 
+// SaveMetadata stores meta as json next to the image at path
+func SaveMetadata(path string, meta searcher.Metadata) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode metadata: %w", err)
+	}
+	return os.WriteFile(path+".json", append(data, '\n'), 0600)
+}
+
 func buildTagSuffix(tags []string) string {
 	if len(tags) == 0 {
 		return ""
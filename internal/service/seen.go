@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// SeenFile is the name of the used images list inside the save dir
+const SeenFile = ".chiasma-seen.json"
+
+// Seen remembers which candidates were used as wallpapers, keeping the most recent Limit keys
+type Seen struct {
+	path  string
+	limit int
+
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// LoadSeen reads the list at path, a missing file is an empty list
+func LoadSeen(path string, limit int) (*Seen, error) {
+	s := &Seen{
+		path:  path,
+		limit: limit,
+		used:  make(map[string]time.Time),
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("read seen list: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.used); err != nil {
+		return s, fmt.Errorf("decode seen list: %w", err)
+	}
+	return s, nil
+}
+
+// When returns the last time key was used, ok is false if it never was
+func (s *Seen) When(key string) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.used[key]
+	return t, ok
+}
+
+// Add marks key as used now and writes the list
+func (s *Seen) Add(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.used[key] = time.Now()
+	s.trim()
+
+	data, err := json.Marshal(s.used)
+	if err != nil {
+		return fmt.Errorf("encode seen list: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// trim drops the oldest keys beyond the limit
+func (s *Seen) trim() {
+	if s.limit <= 0 || len(s.used) <= s.limit {
+		return
+	}
+
+	keys := make([]string, 0, len(s.used))
+	for k := range s.used {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return s.used[keys[i]].After(s.used[keys[j]]) })
+
+	for _, k := range keys[s.limit:] {
+		delete(s.used, k)
+	}
+}
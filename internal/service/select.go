@@ -0,0 +1,112 @@
+package service
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+)
+
+type usedCandidate struct {
+	searcher.Candidate
+	at time.Time
+}
+
+// selectCandidates drops blocked candidates and ranks the rest for res,
+// candidates used before go next, the least recently used first, and fallback candidates last
+func (s *WallpaperService) selectCandidates(candidates []searcher.Candidate, res searcher.Resolution, blocklist []string) []searcher.Candidate {
+	log := s.Log.With().Str("op", "selectCandidates").Logger()
+
+	fresh := make([]searcher.Candidate, 0, len(candidates))
+	var (
+		used      []usedCandidate
+		fallbacks []searcher.Candidate
+	)
+
+	for _, c := range candidates {
+		if c.Fallback != nil {
+			fallbacks = append(fallbacks, c)
+			continue
+		}
+
+		if term, ok := blocked(c, blocklist); ok {
+			log.Debug().Str("key", c.Key()).Str("reject_reason", "blocklist_"+term).Msg("candidate rejected")
+			continue
+		}
+
+		if at, ok := s.usedAt(c); ok {
+			used = append(used, usedCandidate{Candidate: c, at: at})
+			continue
+		}
+
+		fresh = append(fresh, c)
+	}
+
+	selected := searcher.Rank(fresh, res)
+	if len(used) == 0 {
+		return append(selected, fallbacks...)
+	}
+
+	sort.SliceStable(used, func(i, j int) bool { return used[i].at.Before(used[j].at) })
+	old := make([]searcher.Candidate, len(used))
+	for i, u := range used {
+		old[i] = u.Candidate
+	}
+
+	log.Debug().Int("fresh", len(selected)).Int("used", len(used)).Msg("candidates used before are tried last")
+	return append(append(selected, dropSmall(old, res)...), fallbacks...)
+}
+
+func (s *WallpaperService) usedAt(c searcher.Candidate) (time.Time, bool) {
+	key := c.Key()
+	if s.Seen == nil || key == "" {
+		return time.Time{}, false
+	}
+	return s.Seen.When(key)
+}
+
+// described fills in the metadata of a candidate that only knew it after downloading img,
+// so downloaded images are checked against the blocklist and the seen list like listed ones
+func described(c searcher.Candidate, img searcher.Image) (searcher.Candidate, bool) {
+	if c.Key() != "" {
+		return c, false
+	}
+	meta, ok := searcher.MetadataOf(img)
+	if !ok {
+		return c, false
+	}
+	c.Metadata = meta
+	c.URL = meta.PageURL
+	return c, true
+}
+
+// dropSmall removes candidates known to be smaller than res without reordering the rest
+func dropSmall(candidates []searcher.Candidate, res searcher.Resolution) []searcher.Candidate {
+	kept := candidates[:0]
+	for _, c := range candidates {
+		if !c.Known() || (c.Width >= res.Width && c.Height >= res.Height) {
+			kept = append(kept, c)
+		}
+	}
+	return kept
+}
+
+// blocked returns the first blocklist term found in the candidate url, title, author or tags
+func blocked(c searcher.Candidate, blocklist []string) (string, bool) {
+	if len(blocklist) == 0 {
+		return "", false
+	}
+
+	meta := c.Metadata
+	fields := append([]string{c.URL, meta.PageURL, meta.AuthorURL, meta.Title, meta.Author}, meta.Tags...)
+	text := strings.ToLower(strings.Join(fields, "\n"))
+
+	for _, term := range blocklist {
+		term = strings.ToLower(strings.TrimSpace(term))
+		if term != "" && strings.Contains(text, term) {
+			return term, true
+		}
+	}
+	return "", false
+}
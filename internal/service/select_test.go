@@ -0,0 +1,80 @@
+package service
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+func candidate(id string, w, h int, meta searcher.Metadata) searcher.Candidate {
+	meta.Source = "test"
+	return searcher.Candidate{ID: id, Width: w, Height: h, Metadata: meta}
+}
+
+func keys(candidates []searcher.Candidate) []string {
+	k := make([]string, len(candidates))
+	for i, c := range candidates {
+		k[i] = c.ID
+		if c.Fallback != nil {
+			k[i] = "fallback"
+		}
+	}
+	return k
+}
+
+func TestSelectCandidates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), SeenFile)
+	used := `{"test:used-old": "2025-01-01T00:00:00Z", "test:used-new": "2025-06-01T00:00:00Z"}`
+	if err := os.WriteFile(path, []byte(used), 0600); err != nil {
+		t.Fatal(err)
+	}
+	seen, err := LoadSeen(path, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	svc := &WallpaperService{Log: zerolog.Nop(), Seen: seen}
+	res := searcher.Resolution{Width: 1920, Height: 1080}
+
+	candidates := []searcher.Candidate{
+		{Fallback: func(context.Context) ([]searcher.Candidate, error) { return nil, nil }},
+		candidate("used-new", 1920, 1080, searcher.Metadata{}),
+		candidate("portrait", 2000, 3000, searcher.Metadata{}),
+		candidate("unknown", 0, 0, searcher.Metadata{}),
+		candidate("small", 800, 600, searcher.Metadata{}),
+		candidate("stock", 1920, 1080, searcher.Metadata{Tags: []string{"Shutterstock"}}),
+		candidate("used-old", 1920, 1080, searcher.Metadata{}),
+		candidate("wide", 2560, 1440, searcher.Metadata{}),
+	}
+
+	got := keys(svc.selectCandidates(candidates, res, []string{"shutterstock"}))
+	want := []string{"wide", "unknown", "portrait", "used-old", "used-new", "fallback"}
+	if !slices.Equal(got, want) {
+		t.Errorf("selectCandidates = %v, want %v", got, want)
+	}
+}
+
+func TestBlocked(t *testing.T) {
+	tests := []struct {
+		name string
+		c    searcher.Candidate
+		want bool
+	}{
+		{"url", searcher.Candidate{URL: "https://cdn.example.com/WATERMARK.jpg"}, true},
+		{"author", searcher.Candidate{Metadata: searcher.Metadata{Author: "Getty Images"}}, true},
+		{"clean", searcher.Candidate{URL: "https://example.com/a.jpg", Metadata: searcher.Metadata{Title: "forest"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := blocked(tt.c, []string{" watermark", "getty"}); got != tt.want {
+				t.Errorf("blocked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
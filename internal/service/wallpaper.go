@@ -8,12 +8,14 @@ import (
 
 	"github.com/labi-le/chiasma/internal/fs"
 	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/labi-le/chiasma/pkg/browser"
 	"github.com/labi-le/chiasma/pkg/wallpaper/execute"
 	"github.com/rs/zerolog"
 )
 
+// QuerySource provides the search phrase when none is given, e.g. the browser history
 type QuerySource interface {
-	GetLastSearch() (string, error)
+	LastSearch() (browser.Search, error)
 }
 
 type WallpaperService struct {
@@ -21,6 +23,8 @@ type WallpaperService struct {
 	API     searcher.Searcher
 	History QuerySource
 	Setter  execute.Provider
+	// Seen is optional, used candidates are tried last when set
+	Seen *Seen
 }
 
 type UpdateParams struct {
@@ -29,25 +33,32 @@ type UpdateParams struct {
 	SaveDir    string
 	OutputID   string
 	RetryCount int
+	// Blocklist terms reject candidates whose url, title, author or tags contain them
+	Blocklist []string
 }
 
 func (s *WallpaperService) Update(ctx context.Context, params UpdateParams) error {
 	log := s.Log.With().Str("op", "Update").Logger()
 
 	phrase := params.Phrase
-	if phrase == "" {
-		if s.History == nil {
-			return errors.New("search phrase is empty and no history source provided")
+	if phrase == "" && !searcher.IgnoresPhrase(s.API) {
+		search, err := s.searchFromHistory()
+		switch {
+		case err == nil:
+			phrase = search.Query
+			event := log.Info()
+			if search.Engine != "" {
+				event = event.Str("engine", search.Engine)
+			}
+			event.Msgf("using phrase from history: %s", phrase)
+		case searcher.RequiresPhrase(s.API):
+			return err
+		default:
+			log.Warn().Err(err).Msg("continuing without a search phrase")
 		}
-		var err error
-		phrase, err = s.History.GetLastSearch()
-		if err != nil {
-			return fmt.Errorf("failed to get search phrase from history: %w", err)
-		}
-		log.Info().Msgf("using phrase from history: %s", phrase)
 	}
 
-	img, err := s.fetchImageWithRetry(ctx, phrase, params.Resolution, params.RetryCount)
+	img, candidate, err := s.fetchImageWithRetry(ctx, phrase, params)
 	if err != nil {
 		return err
 	}
@@ -66,30 +77,171 @@ func (s *WallpaperService) Update(ctx context.Context, params UpdateParams) erro
 		log.Debug().Str("path", path).Msg("image saved")
 	}
 
+	if meta, ok := searcher.MetadataOf(img); ok {
+		if meta.Width == 0 || meta.Height == 0 {
+			meta.Width, meta.Height = img.Size()
+		}
+		log.Info().EmbedObject(meta).Msg("image metadata")
+		if err := fs.SaveMetadata(path, meta); err != nil {
+			log.Warn().Err(err).Msg("failed to save metadata")
+		}
+	}
+
 	if err := s.Setter.Change(ctx, path, params.OutputID); err != nil {
 		return fmt.Errorf("failed to set wallpaper: %w", err)
 	}
+	candidate.Accept()
+
+	if key := candidate.Key(); key != "" && s.Seen != nil {
+		if err := s.Seen.Add(key); err != nil {
+			log.Warn().Err(err).Msg("failed to save seen list")
+		}
+	}
 
 	return nil
 }
 
-func (s *WallpaperService) fetchImageWithRetry(ctx context.Context, phrase string, res searcher.Resolution, retries int) (searcher.Image, error) {
-	var lastErr error
-	for i := 0; i < retries; i++ {
-		img, err := s.API.Search(ctx, phrase, res)
+func (s *WallpaperService) searchFromHistory() (browser.Search, error) {
+	if s.History == nil {
+		return browser.Search{}, errors.New("search phrase is empty and no history source provided")
+	}
+
+	search, err := s.History.LastSearch()
+	if err != nil {
+		return browser.Search{}, fmt.Errorf("failed to get search phrase from history: %w", err)
+	}
+	return search, nil
+}
+
+// fetchImageWithRetry lists candidates and downloads the selected ones in turn,
+// listing again while nothing fits and at most RetryCount downloads are made from each source.
+// Fallback candidates list the next source once the candidates before them failed
+func (s *WallpaperService) fetchImageWithRetry(ctx context.Context, phrase string, params UpdateParams) (searcher.Image, searcher.Candidate, error) {
+	log := s.Log.With().Str("op", "fetchImageWithRetry").Logger()
+	res := params.Resolution
+
+	type queued struct {
+		searcher.Candidate
+		// source counts the fallbacks listed before the candidate
+		source int
+	}
+	enqueue := func(candidates []searcher.Candidate, source int) []queued {
+		selected := s.selectCandidates(candidates, res, params.Blocklist)
+		q := make([]queued, len(selected))
+		for i, c := range selected {
+			q[i] = queued{Candidate: c, source: source}
+		}
+		return q
+	}
+
+	var (
+		lastErr error
+		// spare is a downloaded image used before, kept in case nothing else is found
+		spare     searcher.Image
+		spareFrom searcher.Candidate
+		fetches   = map[int]int{}
+		total     int
+	)
+	for round := 0; round < params.RetryCount; round++ {
+		candidates, err := s.candidates(ctx, phrase, res)
 		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
 			lastErr = err
 			continue
 		}
 
-		w, h := img.Size()
-		if w < res.Width || h < res.Height {
-			_ = img.Close()
-			lastErr = fmt.Errorf("image too small: %dx%d < %dx%d", w, h, res.Width, res.Height)
+		queue := enqueue(candidates, 0)
+		if len(queue) == 0 {
+			lastErr = fmt.Errorf("%w among %d", searcher.ErrNoCandidates, len(candidates))
 			continue
 		}
 
-		return img, nil
+		tried := 0
+		for len(queue) > 0 {
+			c := queue[0]
+			queue = queue[1:]
+
+			if c.Fallback != nil {
+				more, err := c.Fallback(ctx)
+				if err != nil {
+					lastErr = err
+					continue
+				}
+				queue = append(enqueue(more, c.source+1), queue...)
+				continue
+			}
+
+			if fetches[c.source] >= params.RetryCount {
+				continue
+			}
+			fetches[c.source]++
+			total++
+			tried++
+
+			log.Debug().Str("key", c.Key()).Int("attempt", fetches[c.source]).Msg("trying candidate")
+			img, err := c.Open(ctx)
+			if err == nil {
+				if err = searcher.TooSmall(img, res); err != nil {
+					_ = img.Close()
+				}
+			}
+			if err != nil {
+				if ctx.Err() != nil {
+					break
+				}
+				log.Debug().Err(err).Str("key", c.Key()).Msg("candidate failed")
+				lastErr = err
+				continue
+			}
+
+			found, ok := described(c.Candidate, img)
+			if !ok {
+				if spare != nil {
+					_ = spare.Close()
+				}
+				return img, found, nil
+			}
+			if term, ok := blocked(found, params.Blocklist); ok {
+				log.Debug().Str("key", found.Key()).Str("reject_reason", "blocklist_"+term).Msg("image rejected")
+				_ = img.Close()
+				lastErr = fmt.Errorf("image matches blocklist term %q", term)
+				continue
+			}
+			if _, ok := s.usedAt(found); ok && len(queue) > 0 {
+				if spare != nil {
+					_ = img.Close()
+					continue
+				}
+				log.Debug().Str("key", found.Key()).Msg("image used before, trying other candidates first")
+				spare, spareFrom = img, found
+				continue
+			}
+			if spare != nil {
+				_ = spare.Close()
+			}
+			return img, found, nil
+		}
+
+		if ctx.Err() != nil || spare != nil || tried == 0 {
+			break
+		}
+	}
+
+	if spare != nil {
+		return spare, spareFrom, nil
+	}
+	if ctx.Err() != nil {
+		return nil, searcher.Candidate{}, ctx.Err()
+	}
+	return nil, searcher.Candidate{}, fmt.Errorf("failed to find suitable image after %d attempts: %w", total, lastErr)
+}
+
+// candidates lists the api candidates, a plain searcher is a single candidate searched on download
+func (s *WallpaperService) candidates(ctx context.Context, phrase string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	if cs, ok := s.API.(searcher.CandidateSearcher); ok {
+		return cs.Candidates(ctx, phrase, res)
 	}
-	return nil, fmt.Errorf("failed to find suitable image after %d attempts: %w", retries, lastErr)
+	return []searcher.Candidate{searcher.Lazy(s.API, phrase, res)}, nil
 }
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/multi"
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/labi-le/chiasma/pkg/browser"
+	"github.com/rs/zerolog"
+)
+
+var errTooManyRequests = errors.New("429")
+
+// listing lists n candidates whose downloads all fail
+type listing struct {
+	n       int
+	fetches int
+}
+
+func (l *listing) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, l, q, res)
+}
+
+func (l *listing) Candidates(context.Context, string, searcher.Resolution) ([]searcher.Candidate, error) {
+	candidates := make([]searcher.Candidate, l.n)
+	for i := range candidates {
+		candidates[i] = searcher.Candidate{
+			ID:       fmt.Sprint(i),
+			Metadata: searcher.Metadata{Source: "listing"},
+			Fetch: func(context.Context) (searcher.Image, error) {
+				l.fetches++
+				return nil, errTooManyRequests
+			},
+		}
+	}
+	return candidates, nil
+}
+
+// pngSource always returns a png of the given size
+type pngSource struct{ w, h int }
+
+func (p pngSource) Search(context.Context, string, searcher.Resolution) (searcher.Image, error) {
+	return pngImage(p.w, p.h)
+}
+
+func pngImage(w, h int) (searcher.Image, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		return nil, err
+	}
+	return searcher.DetectSize(io.NopCloser(&buf))
+}
+
+// phraseSource records the phrase of its last search
+type phraseSource struct {
+	pngSource
+	phrase string
+}
+
+func (p *phraseSource) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	p.phrase = q
+	return p.pngSource.Search(ctx, q, res)
+}
+
+// stockSource lists a single candidate titled shutterstock
+type stockSource struct{}
+
+func (stockSource) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, stockSource{}, q, res)
+}
+
+func (stockSource) Candidates(context.Context, string, searcher.Resolution) ([]searcher.Candidate, error) {
+	return []searcher.Candidate{{
+		ID:       "stock",
+		Metadata: searcher.Metadata{Source: "stock", Title: "shutterstock"},
+		Fetch: func(context.Context) (searcher.Image, error) {
+			return pngImage(64, 36)
+		},
+	}}, nil
+}
+
+// stockImage searches a png only described as shutterstock once downloaded
+type stockImage struct{}
+
+func (stockImage) Search(context.Context, string, searcher.Resolution) (searcher.Image, error) {
+	img, err := pngImage(64, 36)
+	if err != nil {
+		return nil, err
+	}
+	return searcher.WithMetadata(img, searcher.Metadata{Source: "stock", PageURL: "https://stock/1", Title: "shutterstock"}), nil
+}
+
+type staticHistory struct{ search browser.Search }
+
+func (h staticHistory) LastSearch() (browser.Search, error) { return h.search, nil }
+
+type recordingSetter struct{ paths []string }
+
+func (r *recordingSetter) Change(_ context.Context, path, _ string) error {
+	r.paths = append(r.paths, path)
+	return nil
+}
+
+func TestUpdateFallsBackWhenFirstSourceCandidatesFail(t *testing.T) {
+	first := &listing{n: 10}
+	api := multi.NewFallback(zerolog.Nop(), time.Second,
+		multi.Source{Name: "first", Searcher: first},
+		multi.Source{Name: "second", Searcher: pngSource{w: 64, h: 36}},
+	)
+	setter := &recordingSetter{}
+	svc := &WallpaperService{Log: zerolog.Nop(), API: api, Setter: setter}
+
+	err := svc.Update(context.Background(), UpdateParams{
+		Phrase:     "forest",
+		Resolution: searcher.Resolution{Width: 32, Height: 18},
+		SaveDir:    t.TempDir(),
+		RetryCount: 5,
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if len(setter.paths) != 1 {
+		t.Fatalf("wallpaper set %d times, want 1", len(setter.paths))
+	}
+	if first.fetches != 5 {
+		t.Errorf("first source downloaded %d times, want 5", first.fetches)
+	}
+}
+
+func TestUpdateFallbackKeepsBlocklist(t *testing.T) {
+	for name, second := range map[string]searcher.Searcher{"candidates": stockSource{}, "search": stockImage{}} {
+		t.Run(name, func(t *testing.T) {
+			first := &listing{n: 2}
+			api := multi.NewFallback(zerolog.Nop(), time.Second,
+				multi.Source{Name: "first", Searcher: first},
+				multi.Source{Name: "second", Searcher: second},
+			)
+			setter := &recordingSetter{}
+			svc := &WallpaperService{Log: zerolog.Nop(), API: api, Setter: setter}
+
+			err := svc.Update(context.Background(), UpdateParams{
+				Phrase:     "forest",
+				Resolution: searcher.Resolution{Width: 32, Height: 18},
+				SaveDir:    t.TempDir(),
+				RetryCount: 5,
+				Blocklist:  []string{"shutterstock"},
+			})
+			if err == nil {
+				t.Fatal("Update set a blocked wallpaper")
+			}
+			if len(setter.paths) != 0 {
+				t.Errorf("wallpaper set %d times, want 0", len(setter.paths))
+			}
+		})
+	}
+}
+
+func TestUpdateFailsWithinBudget(t *testing.T) {
+	first := &listing{n: 10}
+	svc := &WallpaperService{Log: zerolog.Nop(), API: first, Setter: &recordingSetter{}}
+
+	err := svc.Update(context.Background(), UpdateParams{
+		Phrase:     "forest",
+		Resolution: searcher.Resolution{Width: 32, Height: 18},
+		SaveDir:    t.TempDir(),
+		RetryCount: 3,
+	})
+	if !errors.Is(err, errTooManyRequests) {
+		t.Fatalf("Update error = %v, want %v", err, errTooManyRequests)
+	}
+	if first.fetches != 3 {
+		t.Errorf("downloaded %d times, want 3", first.fetches)
+	}
+}
+
+func TestUpdateUsesHistorySearch(t *testing.T) {
+	api := &phraseSource{pngSource: pngSource{w: 64, h: 36}}
+	var logs bytes.Buffer
+	svc := &WallpaperService{
+		Log:     zerolog.New(&logs),
+		API:     api,
+		History: staticHistory{browser.Search{Query: "aurora borealis", Engine: "duckduckgo"}},
+		Setter:  &recordingSetter{},
+	}
+
+	err := svc.Update(context.Background(), UpdateParams{
+		Resolution: searcher.Resolution{Width: 32, Height: 18},
+		SaveDir:    t.TempDir(),
+		RetryCount: 1,
+	})
+	if err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if api.phrase != "aurora borealis" {
+		t.Errorf("searched %q, want the history phrase", api.phrase)
+	}
+	if !strings.Contains(logs.String(), `"engine":"duckduckgo"`) {
+		t.Errorf("history engine not logged: %s", logs.String())
+	}
+}
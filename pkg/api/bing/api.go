@@ -0,0 +1,276 @@
+package bing
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "bing"
+
+const (
+	baseURL    = "https://www.bing.com"
+	archiveURL = baseURL + "/HPImageArchive.aspx?format=js&idx=0&n=1&mkt=%s"
+
+	// bing renders nothing larger than UHD
+	uhdWidth  = 3840
+	uhdHeight = 2160
+
+	// archiveRecheck spaces archive requests once the image of the day should have changed,
+	// bing switches images at an hour depending on the market rather than at local midnight
+	archiveRecheck = time.Hour
+)
+
+var (
+	ErrEmptyArchive = errors.New("bing: image archive is empty")
+	ErrAboveUHD     = fmt.Errorf("bing: images are at most %dx%d", uhdWidth, uhdHeight)
+
+	// variants are the fixed sizes bing renders besides UHD
+	variants = []searcher.Resolution{
+		{Width: 1920, Height: 1200},
+		{Width: 1920, Height: 1080},
+		{Width: 1366, Height: 768},
+		{Width: 1280, Height: 768},
+		{Width: 1280, Height: 720},
+		{Width: 1024, Height: 768},
+		{Width: 1080, Height: 1920},
+		{Width: 768, Height: 1366},
+		{Width: 768, Height: 1280},
+		{Width: 720, Height: 1280},
+	}
+)
+
+// Options configures the bing searcher
+type Options struct {
+	// Market is the bing locale, e.g. "en-US"
+	Market string
+	// CacheDir keeps downloaded images, defaults to the user cache directory
+	CacheDir string
+}
+
+type Bing struct {
+	log    zerolog.Logger
+	client http.Client
+	opts   Options
+
+	mu      sync.Mutex
+	fetched time.Time
+	entry   archiveImage
+}
+
+func NewBing(log zerolog.Logger, opts Options) (*Bing, error) {
+	if opts.Market == "" {
+		opts.Market = "en-US"
+	}
+
+	if opts.CacheDir == "" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return nil, fmt.Errorf("bing: resolve cache dir: %w", err)
+		}
+		opts.CacheDir = filepath.Join(dir, "chiasma", Name)
+	}
+
+	return &Bing{
+		log:  log.With().Str("component", "bing").Logger(),
+		opts: opts,
+	}, nil
+}
+
+type archiveImage struct {
+	StartDate     string `json:"startdate"`
+	EndDate       string `json:"enddate"`
+	URLBase       string `json:"urlbase"`
+	Copyright     string `json:"copyright"`
+	CopyrightLink string `json:"copyrightlink"`
+	Title         string `json:"title"`
+}
+
+type archive struct {
+	Images []archiveImage `json:"images"`
+}
+
+// metadata splits "Place, Country (© Author/Agency)" copyrights into description and author
+func (a archiveImage) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:      Name,
+		Title:       a.Title,
+		Description: a.Copyright,
+		PageURL:     a.CopyrightLink,
+	}
+
+	if open := strings.LastIndex(a.Copyright, "("); open >= 0 && strings.HasSuffix(a.Copyright, ")") {
+		meta.Description = strings.TrimSpace(a.Copyright[:open])
+		meta.Author = strings.TrimSpace(strings.TrimPrefix(a.Copyright[open+1:len(a.Copyright)-1], "©"))
+	}
+	if meta.Title == "" {
+		meta.Title = meta.Description
+	}
+	return meta
+}
+
+func (b *Bing) IgnoresPhrase() bool { return true }
+
+func (b *Bing) Search(ctx context.Context, _ string, res searcher.Resolution) (searcher.Image, error) {
+	log := b.log.With().Str("op", "Search").Logger()
+
+	if res.Width > uhdWidth || res.Height > uhdHeight {
+		return nil, fmt.Errorf("%w, %s is larger", ErrAboveUHD, res.String())
+	}
+
+	entry, err := b.today(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := filepath.Join(b.opts.CacheDir, fmt.Sprintf("%s_%s.jpg", entry.StartDate, res.String()))
+	if f, err := os.Open(cached); err == nil {
+		log.Debug().Str("path", cached).Msg("using cached image of the day")
+		return b.wrap(f, entry)
+	}
+
+	imgURL := imageURL(entry.URLBase, res)
+	log.Debug().Str("url", imgURL).Msg("downloading image of the day")
+
+	if err := b.download(ctx, imgURL, cached); err != nil {
+		return nil, err
+	}
+	prune(log, b.opts.CacheDir, entry.StartDate)
+
+	f, err := os.Open(cached)
+	if err != nil {
+		return nil, fmt.Errorf("open cached image: %w", err)
+	}
+
+	return b.wrap(f, entry)
+}
+
+func (b *Bing) wrap(f *os.File, entry archiveImage) (searcher.Image, error) {
+	img, err := searcher.DetectSize(f)
+	if err != nil {
+		_ = f.Close()
+		return nil, err
+	}
+
+	return searcher.WithMetadata(img, entry.metadata()), nil
+}
+
+// today returns the archive entry, fetching it again once its enddate is reached
+func (b *Bing) today(ctx context.Context) (archiveImage, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.entry.StartDate != "" && (time.Now().Format("20060102") < b.entry.EndDate || time.Since(b.fetched) < archiveRecheck) {
+		return b.entry, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(archiveURL, url.QueryEscape(b.opts.Market)), nil)
+	if err != nil {
+		return archiveImage{}, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return archiveImage{}, fmt.Errorf("do archive req: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return archiveImage{}, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	var a archive
+	if err := json.NewDecoder(resp.Body).Decode(&a); err != nil {
+		return archiveImage{}, fmt.Errorf("decode json: %w", err)
+	}
+
+	if len(a.Images) == 0 {
+		return archiveImage{}, ErrEmptyArchive
+	}
+
+	b.fetched = time.Now()
+	b.entry = a.Images[0]
+
+	return b.entry, nil
+}
+
+func (b *Bing) download(ctx context.Context, imgURL string, dst string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
+	if err != nil {
+		return fmt.Errorf("create img req: %w", err)
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("download img: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("img status: %d", resp.StatusCode)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+
+	tmp := dst + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write img: %w", err)
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, dst)
+}
+
+// prune removes cached images of days before startDate
+func prune(log zerolog.Logger, dir string, startDate string) {
+	cached, _ := filepath.Glob(filepath.Join(dir, "*_*.jpg"))
+	for _, path := range cached {
+		day, _, _ := strings.Cut(filepath.Base(path), "_")
+		if day >= startDate {
+			continue
+		}
+		if err := os.Remove(path); err != nil {
+			log.Warn().Err(err).Str("path", path).Msg("failed to remove old image of the day")
+		}
+	}
+}
+
+// imageURL picks the fixed variant matching res or lets bing crop the UHD original
+func imageURL(urlBase string, res searcher.Resolution) string {
+	for _, v := range variants {
+		if v == res {
+			return fmt.Sprintf("%s%s_%s.jpg", baseURL, urlBase, v.String())
+		}
+	}
+
+	u := fmt.Sprintf("%s%s_UHD.jpg", baseURL, urlBase)
+	if res.Width > 0 && res.Height > 0 {
+		u += fmt.Sprintf("&w=%d&h=%d&rs=1&c=4", res.Width, res.Height)
+	}
+
+	return u
+}
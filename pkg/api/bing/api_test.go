@@ -0,0 +1,48 @@
+package bing
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+func TestPrune(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"20260101_1920x1080.jpg", "20260102_1920x1080.jpg", "20260102_3840x2160.jpg", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	prune(zerolog.Nop(), dir, "20260102")
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if want := []string{"20260102_1920x1080.jpg", "20260102_3840x2160.jpg", "notes.txt"}; !slices.Equal(got, want) {
+		t.Errorf("kept %q, want %q", got, want)
+	}
+}
+
+func TestSearchAboveUHD(t *testing.T) {
+	b, err := NewBing(zerolog.Nop(), Options{CacheDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = b.Search(context.Background(), "", searcher.Resolution{Width: 5120, Height: 1440})
+	if !errors.Is(err, ErrAboveUHD) {
+		t.Errorf("Search error = %v, want %v", err, ErrAboveUHD)
+	}
+}
@@ -0,0 +1,24 @@
+package bing
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "bing image of the day",
+		Phrase:       searcher.PhraseIgnored,
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.Market, "bing-market", "en-US", "bing image of the day market")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewBing(log, opts)
+			}
+		},
+	})
+}
@@ -0,0 +1,212 @@
+package external
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+// Prefix is prepended to the source name to find its executable in PATH
+const Prefix = "chiasma-source-"
+
+var (
+	ErrEmptyResponse = errors.New("external: source produced no image")
+)
+
+// Request is written as json to the source's stdin
+type Request struct {
+	Query  string `json:"query"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	// Output is a file the source may write the image to
+	Output string `json:"output"`
+}
+
+// Response is read from stdout when it is a json object,
+// exactly one of Path, URL or Data is expected
+type Response struct {
+	Path     string             `json:"path"`
+	URL      string             `json:"url"`
+	Data     []byte             `json:"data"`
+	Metadata *searcher.Metadata `json:"metadata"`
+	// Caption is a shorthand for metadata.title
+	Caption string `json:"caption"`
+}
+
+// Lookup finds the executable implementing the named source
+func Lookup(name string) (string, bool) {
+	bin, err := exec.LookPath(Prefix + name)
+	return bin, err == nil
+}
+
+// External runs an executable per search, stdout may also be raw image bytes or a single path or url line
+type External struct {
+	log    zerolog.Logger
+	client http.Client
+	name   string
+	bin    string
+}
+
+func NewExternal(log zerolog.Logger, name string, bin string) *External {
+	return &External{
+		log:  log.With().Str("component", "external").Str("source", name).Logger(),
+		name: name,
+		bin:  bin,
+	}
+}
+
+// AcceptsEmptyPhrase is true since the source decides what an empty query means
+func (e *External) AcceptsEmptyPhrase() bool { return true }
+
+func (e *External) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	log := e.log.With().Str("op", "Search").Logger()
+
+	out, err := os.CreateTemp("", "chiasma-"+e.name+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("create output file: %w", err)
+	}
+	output := out.Name()
+	_ = out.Close()
+
+	img, err := e.run(ctx, Request{Query: q, Width: res.Width, Height: res.Height, Output: output})
+	if err != nil || !img.usesOutput {
+		_ = os.Remove(output)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	log.Debug().Msg("source returned an image")
+	return img.Image, nil
+}
+
+type result struct {
+	searcher.Image
+	usesOutput bool
+}
+
+func (e *External) run(ctx context.Context, req Request) (result, error) {
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return result{}, fmt.Errorf("encode request: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.bin)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return result{}, fmt.Errorf("%s: %w: %s", e.bin, err, strings.TrimSpace(stderr.String()))
+	}
+	if stderr.Len() > 0 {
+		e.log.Debug().Str("stderr", strings.TrimSpace(stderr.String())).Msg("source output")
+	}
+
+	resp, err := parseResponse(stdout.Bytes())
+	if err != nil {
+		return result{}, err
+	}
+
+	// a source that only wrote the output file is answered by that file
+	if resp.Path == "" && resp.URL == "" && len(resp.Data) == 0 {
+		if info, err := os.Stat(req.Output); err == nil && info.Size() > 0 {
+			resp.Path = req.Output
+		}
+	}
+
+	img, err := e.open(ctx, resp, resp.Path == req.Output)
+	if err != nil {
+		return result{}, err
+	}
+
+	if resp.Metadata != nil || resp.Caption != "" {
+		var meta searcher.Metadata
+		if resp.Metadata != nil {
+			meta = *resp.Metadata
+		}
+		if meta.Title == "" {
+			meta.Title = resp.Caption
+		}
+		if meta.Source == "" {
+			meta.Source = e.name
+		}
+		img = searcher.WithMetadata(img, meta)
+	}
+	return result{Image: img, usesOutput: resp.Path == req.Output}, nil
+}
+
+func (e *External) open(ctx context.Context, resp Response, temporary bool) (searcher.Image, error) {
+	switch {
+	case len(resp.Data) > 0:
+		return searcher.DetectSize(bytes.NewReader(resp.Data))
+	case resp.Path != "":
+		f, err := os.Open(resp.Path)
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", resp.Path, err)
+		}
+		var r io.ReadCloser = f
+		if temporary {
+			r = tempFile{f}
+		}
+		img, err := searcher.DetectSize(r)
+		if err != nil {
+			_ = f.Close()
+			return nil, err
+		}
+		return img, nil
+	case resp.URL != "":
+		return searcher.Download(ctx, &e.client, resp.URL)
+	default:
+		return nil, ErrEmptyResponse
+	}
+}
+
+// tempFile removes the suggested output file once the image has been consumed
+type tempFile struct {
+	*os.File
+}
+
+func (t tempFile) Close() error {
+	err := t.File.Close()
+	if rmErr := os.Remove(t.Name()); rmErr != nil && err == nil {
+		err = rmErr
+	}
+	return err
+}
+
+// parseResponse accepts a json object, raw image bytes or a single path/url line
+func parseResponse(stdout []byte) (Response, error) {
+	trimmed := bytes.TrimSpace(stdout)
+
+	switch {
+	case len(trimmed) == 0:
+		return Response{}, nil
+	case trimmed[0] == '{':
+		var resp Response
+		if err := json.Unmarshal(trimmed, &resp); err != nil {
+			return Response{}, fmt.Errorf("decode response: %w", err)
+		}
+		return resp, nil
+	case strings.HasPrefix(mimetype.Detect(stdout).String(), "image/"):
+		return Response{Data: stdout}, nil
+	}
+
+	line := string(trimmed)
+	if strings.HasPrefix(line, "http://") || strings.HasPrefix(line, "https://") {
+		return Response{URL: line}, nil
+	}
+	return Response{Path: line}, nil
+}
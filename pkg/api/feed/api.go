@@ -0,0 +1,383 @@
+package feed
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+const Name = "feed"
+
+var (
+	ErrNoFeeds  = errors.New("feed: no feed urls configured")
+	ErrNoImages = errors.New("feed: no image enclosures found")
+
+	// imgTag finds images embedded in html descriptions, e.g. the wikimedia picture of the day feed
+	imgTag = regexp.MustCompile(`<img[^>]+src="([^"]+)"`)
+)
+
+// Options configures the feed searcher
+type Options struct {
+	URLs []string
+	// MatchPhrase keeps only entries whose title or description contain every phrase term
+	MatchPhrase bool
+}
+
+type Feed struct {
+	log    zerolog.Logger
+	client http.Client
+	opts   Options
+}
+
+func NewFeed(log zerolog.Logger, opts Options) (*Feed, error) {
+	if len(opts.URLs) == 0 {
+		return nil, ErrNoFeeds
+	}
+
+	return &Feed{
+		log:  log.With().Str("component", "feed").Logger(),
+		opts: opts,
+	}, nil
+}
+
+// document covers rss 2.0 (channel/item), rss 1.0 (item) and atom (entry)
+type document struct {
+	Channel struct {
+		Items []item `xml:"item"`
+	} `xml:"channel"`
+	Items   []item `xml:"item"`
+	Entries []item `xml:"entry"`
+}
+
+type item struct {
+	Title       string         `xml:"title"`
+	Description string         `xml:"description"`
+	Summary     string         `xml:"summary"`
+	Content     string         `xml:"http://www.w3.org/2005/Atom content"`
+	Encoded     string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	Links       []link         `xml:"link"`
+	Authors     []author       `xml:"author"`
+	Creator     string         `xml:"http://purl.org/dc/elements/1.1/ creator"`
+	Credits     []string       `xml:"http://search.yahoo.com/mrss/ credit"`
+	Rights      string         `xml:"rights"`
+	Copyright   string         `xml:"http://search.yahoo.com/mrss/ copyright"`
+	Categories  []category     `xml:"category"`
+	Enclosures  []enclosure    `xml:"enclosure"`
+	Media       []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	Groups      []struct {
+		Media []mediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+	} `xml:"http://search.yahoo.com/mrss/ group"`
+}
+
+// link is an atom link or the text of an rss link
+type link struct {
+	Text   string `xml:",chardata"`
+	Href   string `xml:"href,attr"`
+	Rel    string `xml:"rel,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+// author is an atom author element or the text of an rss one
+type author struct {
+	Text string `xml:",chardata"`
+	Name string `xml:"name"`
+	URI  string `xml:"uri"`
+}
+
+type category struct {
+	Text string `xml:",chardata"`
+	Term string `xml:"term,attr"`
+}
+
+type enclosure struct {
+	URL    string `xml:"url,attr"`
+	Type   string `xml:"type,attr"`
+	Length int64  `xml:"length,attr"`
+}
+
+type mediaContent struct {
+	URL      string `xml:"url,attr"`
+	Type     string `xml:"type,attr"`
+	Medium   string `xml:"medium,attr"`
+	Width    int    `xml:"width,attr"`
+	Height   int    `xml:"height,attr"`
+	FileSize int64  `xml:"fileSize,attr"`
+}
+
+// source is a single downloadable image of an entry
+type source struct {
+	url  string
+	w, h int
+	size int64
+}
+
+func (f *Feed) IgnoresPhrase() bool { return !f.opts.MatchPhrase }
+
+// AcceptsEmptyPhrase is true since an empty phrase matches every entry
+func (f *Feed) AcceptsEmptyPhrase() bool { return true }
+
+func (f *Feed) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, f, q, res)
+}
+
+func (f *Feed) Candidates(ctx context.Context, q string, _ searcher.Resolution) ([]searcher.Candidate, error) {
+	log := f.log.With().Str("op", "Candidates").Logger()
+
+	items := f.fetchAll(ctx)
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+
+	terms := strings.Fields(strings.ToLower(q))
+	candidates := make([]searcher.Candidate, 0, len(items))
+
+	for _, it := range items {
+		if f.opts.MatchPhrase && !it.matches(terms) {
+			continue
+		}
+
+		best, ok := it.best()
+		if !ok {
+			log.Debug().Str("title", it.Title).Msg("entry has no image enclosure")
+			continue
+		}
+
+		meta := it.metadata()
+		meta.Width, meta.Height = best.w, best.h
+		candidates = append(candidates, searcher.Candidate{
+			URL:      best.url,
+			Width:    best.w,
+			Height:   best.h,
+			Metadata: meta,
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searcher.Download(ctx, &f.client, best.url)
+			},
+		})
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w among %d entries", ErrNoImages, len(items))
+	}
+	log.Info().Int("total", len(items)).Int("clean", len(candidates)).Msg("filtering complete")
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	return candidates, nil
+}
+
+// fetchAll reads every configured feed, a broken feed only produces a warning
+func (f *Feed) fetchAll(ctx context.Context) []item {
+	var items []item
+	for _, u := range f.opts.URLs {
+		fetched, err := f.fetch(ctx, u)
+		if err != nil {
+			f.log.Warn().Err(err).Str("url", u).Msg("failed to read feed")
+			continue
+		}
+		items = append(items, fetched...)
+	}
+	return items
+}
+
+func (f *Feed) fetch(ctx context.Context, feedURL string) ([]item, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, feedURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do feed req: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("feed status: %d", resp.StatusCode)
+	}
+
+	var doc document
+	dec := xml.NewDecoder(resp.Body)
+	dec.CharsetReader = charsetReader
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("decode xml: %w", err)
+	}
+
+	items := make([]item, 0, len(doc.Channel.Items)+len(doc.Items)+len(doc.Entries))
+	items = append(items, doc.Channel.Items...)
+	items = append(items, doc.Items...)
+	items = append(items, doc.Entries...)
+
+	return items, nil
+}
+
+// charsetReader decodes feeds declaring an encoding other than utf-8, labels are resolved as browsers do
+func charsetReader(label string, input io.Reader) (io.Reader, error) {
+	enc, err := htmlindex.Get(label)
+	if err != nil {
+		return nil, fmt.Errorf("feed charset %q: %w", label, err)
+	}
+	return enc.NewDecoder().Reader(input), nil
+}
+
+func (it item) text() string {
+	return strings.ToLower(strings.Join([]string{it.Title, it.Description, it.Summary, it.Content, it.Encoded}, " "))
+}
+
+func (it item) matches(terms []string) bool {
+	text := it.text()
+	for _, term := range terms {
+		if !strings.Contains(text, term) {
+			return false
+		}
+	}
+	return true
+}
+
+func (it item) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:  Name,
+		Title:   strings.TrimSpace(it.Title),
+		License: strings.TrimSpace(it.Rights),
+	}
+	if meta.License == "" {
+		meta.License = strings.TrimSpace(it.Copyright)
+	}
+
+	for _, l := range it.Links {
+		switch {
+		case l.Href == "" && strings.TrimSpace(l.Text) != "":
+			meta.PageURL = strings.TrimSpace(l.Text)
+		case l.Rel == "" || l.Rel == "alternate":
+			meta.PageURL = l.Href
+		default:
+			continue
+		}
+		break
+	}
+
+	for _, a := range it.Authors {
+		meta.Author = strings.TrimSpace(a.Name)
+		if meta.Author == "" {
+			meta.Author = strings.TrimSpace(a.Text)
+		}
+		meta.AuthorURL = strings.TrimSpace(a.URI)
+		if meta.Author != "" {
+			break
+		}
+	}
+	if meta.Author == "" {
+		meta.Author = strings.TrimSpace(it.Creator)
+	}
+	if meta.Author == "" && len(it.Credits) > 0 {
+		meta.Author = strings.TrimSpace(it.Credits[0])
+	}
+
+	for _, c := range it.Categories {
+		tag := strings.TrimSpace(c.Term)
+		if tag == "" {
+			tag = strings.TrimSpace(c.Text)
+		}
+		if tag != "" {
+			meta.Tags = append(meta.Tags, tag)
+		}
+	}
+
+	return meta
+}
+
+// best picks the largest image among media:content, enclosures and, as a last resort, images in the html body
+func (it item) best() (source, bool) {
+	var sources []source
+
+	media := it.Media
+	for _, g := range it.Groups {
+		media = append(media, g.Media...)
+	}
+	for _, m := range media {
+		if isImage(m.URL, m.Type, m.Medium) {
+			sources = append(sources, source{url: m.URL, w: m.Width, h: m.Height, size: m.FileSize})
+		}
+	}
+
+	for _, e := range it.Enclosures {
+		if isImage(e.URL, e.Type, "") {
+			sources = append(sources, source{url: e.URL, size: e.Length})
+		}
+	}
+
+	for _, l := range it.Links {
+		if l.Rel == "enclosure" && isImage(l.Href, l.Type, "") {
+			sources = append(sources, source{url: l.Href, size: l.Length})
+		}
+	}
+
+	if len(sources) == 0 {
+		for _, body := range []string{it.Description, it.Summary, it.Content, it.Encoded} {
+			for _, m := range imgTag.FindAllStringSubmatch(html.UnescapeString(body), -1) {
+				sources = append(sources, source{url: originalURL(m[1])})
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return source{}, false
+	}
+
+	best := sources[0]
+	for _, s := range sources[1:] {
+		if s.w*s.h > best.w*best.h || (s.w*s.h == best.w*best.h && s.size > best.size) {
+			best = s
+		}
+	}
+
+	return best, true
+}
+
+func isImage(u, mimeType, medium string) bool {
+	if u == "" {
+		return false
+	}
+	if medium != "" {
+		return medium == "image"
+	}
+	if mimeType != "" {
+		return strings.HasPrefix(mimeType, "image/")
+	}
+
+	switch strings.ToLower(path.Ext(u)) {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// originalURL turns protocol-relative links into https and wikimedia thumbnails into the original file
+func originalURL(src string) string {
+	if strings.HasPrefix(src, "//") {
+		src = "https:" + src
+	}
+
+	u, err := url.Parse(src)
+	if err != nil || u.Host != "upload.wikimedia.org" || !strings.Contains(u.Path, "/thumb/") {
+		return src
+	}
+
+	// .../commons/thumb/a/ab/File.jpg/300px-File.jpg -> .../commons/a/ab/File.jpg
+	u.Path = path.Dir(strings.Replace(u.Path, "/thumb/", "/", 1))
+
+	return u.String()
+}
@@ -0,0 +1,51 @@
+package feed
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rs/zerolog"
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestFetchDecodesCharset(t *testing.T) {
+	tests := []struct {
+		name  string
+		label string
+		enc   *charmap.Charmap
+		title string
+	}{
+		{"latin1", "ISO-8859-1", charmap.ISO8859_1, "Café à Genève"},
+		{"cyrillic", "windows-1251", charmap.Windows1251, "Зимний лес"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := `<?xml version="1.0" encoding="` + tt.label + `"?>
+<rss version="2.0"><channel><item><title>` + tt.title + `</title></item></channel></rss>`
+			body, err := tt.enc.NewEncoder().String(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(body))
+			}))
+			defer srv.Close()
+
+			f, err := NewFeed(zerolog.Nop(), Options{URLs: []string{srv.URL}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			items, err := f.fetch(context.Background(), srv.URL)
+			if err != nil {
+				t.Fatalf("fetch: %v", err)
+			}
+			if len(items) != 1 || items[0].Title != tt.title {
+				t.Errorf("items = %+v, want the title %q", items, tt.title)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package feed
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "rss/atom/media-rss feeds",
+		Phrase:       searcher.PhraseIgnored,
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringSliceVar(&opts.URLs, "feed-url", nil, "rss/atom feed url, can be repeated")
+			fs.BoolVar(&opts.MatchPhrase, "feed-match", false, "only use feed entries matching the search phrase")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewFeed(log, opts)
+			}
+		},
+	})
+}
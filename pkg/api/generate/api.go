@@ -0,0 +1,144 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/png"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "generate"
+
+const (
+	defaultWidth  = 1920
+	defaultHeight = 1080
+)
+
+var (
+	ErrUnknownPattern = errors.New("generate: unknown pattern")
+)
+
+// Options configures the generator, an empty Pattern lets the seed choose one
+type Options struct {
+	Pattern string
+}
+
+type Generator struct {
+	log  zerolog.Logger
+	opts Options
+}
+
+func NewGenerator(log zerolog.Logger, opts Options) (*Generator, error) {
+	if opts.Pattern != "" {
+		if _, ok := patterns[opts.Pattern]; !ok {
+			return nil, fmt.Errorf("%w: %s (available: %v)", ErrUnknownPattern, opts.Pattern, patternNames)
+		}
+	}
+
+	return &Generator{
+		log:  log.With().Str("component", "generate").Logger(),
+		opts: opts,
+	}, nil
+}
+
+// AcceptsEmptyPhrase is true since an empty phrase just means a random seed
+func (g *Generator) AcceptsEmptyPhrase() bool { return true }
+
+// Search renders a wallpaper, the same phrase always produces the same image
+func (g *Generator) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	log := g.log.With().Str("op", "Search").Logger()
+
+	w, h := res.Width, res.Height
+	if w <= 0 || h <= 0 {
+		w, h = defaultWidth, defaultHeight
+	}
+
+	seed := seedOf(q)
+	rng := rand.New(rand.NewPCG(seed, seed^0x9e3779b97f4a7c15))
+
+	name := g.opts.Pattern
+	if name == "" {
+		name = patternNames[rng.IntN(len(patternNames))]
+	}
+
+	pal := newPalette(rng)
+	shade := patterns[name](rng, pal, w, h)
+
+	log.Debug().Str("pattern", name).Uint64("seed", seed).Int("w", w).Int("h", h).Msg("rendering")
+
+	img, err := render(ctx, w, h, shade)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+
+	out, err := searcher.DetectSize(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.WithMetadata(out, searcher.Metadata{
+		Source: Name,
+		Title:  fmt.Sprintf("%s, seed %016x", name, seed),
+		Tags:   []string{name},
+	}), nil
+}
+
+// seedOf hashes the phrase into a seed, an empty phrase gives a random one
+func seedOf(q string) uint64 {
+	if q == "" {
+		return rand.Uint64()
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(q))
+	return h.Sum64()
+}
+
+// render evaluates shade for every pixel, splitting rows between cpus
+func render(ctx context.Context, w, h int, shade shader) (*image.RGBA, error) {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	workers := runtime.NumCPU()
+	rows := make(chan int, h)
+	for y := 0; y < h; y++ {
+		rows <- y
+	}
+	close(rows)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for y := range rows {
+				if ctx.Err() != nil {
+					return
+				}
+				for x := 0; x < w; x++ {
+					img.SetRGBA(x, y, shade(x, y))
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	return img, nil
+}
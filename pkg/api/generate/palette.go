@@ -0,0 +1,100 @@
+package generate
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+)
+
+// palette is an ordered set of color stops sampled with linear interpolation
+type palette []color.RGBA
+
+// newPalette builds a harmonious palette around a random base hue
+func newPalette(rng *rand.Rand) palette {
+	base := rng.Float64()
+
+	// hue offsets for analogous, complementary, triadic and split-complementary schemes
+	schemes := [][]float64{
+		{0, 0.05, 0.1, 0.15},
+		{0, 0.04, 0.5, 0.54},
+		{0, 1.0 / 3, 2.0 / 3},
+		{0, 0.42, 0.58},
+	}
+	offsets := schemes[rng.IntN(len(schemes))]
+
+	// dark to light so gradients and noise read well on a desktop
+	p := make(palette, 0, len(offsets))
+	for i, off := range offsets {
+		t := float64(i) / float64(len(offsets)-1)
+		sat := 0.45 + rng.Float64()*0.35
+		light := 0.18 + t*0.45 + rng.Float64()*0.08
+		p = append(p, hsl(math.Mod(base+off, 1), sat, light))
+	}
+
+	return p
+}
+
+// at samples the palette at t in [0, 1]
+func (p palette) at(t float64) color.RGBA {
+	t = clamp(t, 0, 1)
+
+	pos := t * float64(len(p)-1)
+	i := int(pos)
+	if i >= len(p)-1 {
+		return p[len(p)-1]
+	}
+
+	return lerpColor(p[i], p[i+1], pos-float64(i))
+}
+
+func lerpColor(a, b color.RGBA, t float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(float64(a.R) + (float64(b.R)-float64(a.R))*t),
+		G: uint8(float64(a.G) + (float64(b.G)-float64(a.G))*t),
+		B: uint8(float64(a.B) + (float64(b.B)-float64(a.B))*t),
+		A: 0xff,
+	}
+}
+
+// scale darkens (f < 1) or lightens (f > 1) c
+func scale(c color.RGBA, f float64) color.RGBA {
+	return color.RGBA{
+		R: uint8(clamp(float64(c.R)*f, 0, 255)),
+		G: uint8(clamp(float64(c.G)*f, 0, 255)),
+		B: uint8(clamp(float64(c.B)*f, 0, 255)),
+		A: 0xff,
+	}
+}
+
+func hsl(h, s, l float64) color.RGBA {
+	q := l + s - l*s
+	if l < 0.5 {
+		q = l * (1 + s)
+	}
+	p := 2*l - q
+
+	return color.RGBA{
+		R: uint8(255 * hueToRGB(p, q, h+1.0/3)),
+		G: uint8(255 * hueToRGB(p, q, h)),
+		B: uint8(255 * hueToRGB(p, q, h-1.0/3)),
+		A: 0xff,
+	}
+}
+
+func hueToRGB(p, q, t float64) float64 {
+	t = math.Mod(t+1, 1)
+	switch {
+	case t < 1.0/6:
+		return p + (q-p)*6*t
+	case t < 0.5:
+		return q
+	case t < 2.0/3:
+		return p + (q-p)*(2.0/3-t)*6
+	default:
+		return p
+	}
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
@@ -0,0 +1,182 @@
+package generate
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+)
+
+// shader returns the color of a single pixel, it must be safe for concurrent use
+type shader func(x, y int) color.RGBA
+
+type pattern func(rng *rand.Rand, pal palette, w, h int) shader
+
+var (
+	patterns = map[string]pattern{
+		"gradient":  gradient,
+		"plasma":    plasma,
+		"voronoi":   voronoi,
+		"geometric": geometric,
+	}
+
+	// patternNames is sorted so a seed always maps to the same pattern
+	patternNames = []string{"geometric", "gradient", "plasma", "voronoi"}
+)
+
+// gradient is a linear or radial blend through the palette with a little grain against banding
+func gradient(rng *rand.Rand, pal palette, w, h int) shader {
+	angle := rng.Float64() * 2 * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	radial := rng.IntN(3) == 0
+	cx, cy := rng.Float64(), rng.Float64()
+	salt := rng.Uint64()
+
+	fw, fh := float64(w), float64(h)
+	diag := math.Hypot(fw, fh)
+
+	return func(x, y int) color.RGBA {
+		px, py := float64(x)/fw, float64(y)/fh
+
+		var t float64
+		if radial {
+			t = math.Hypot((px-cx)*fw, (py-cy)*fh) / diag * 1.5
+		} else {
+			t = ((px-0.5)*dx*fw+(py-0.5)*dy*fh)/diag + 0.5
+		}
+
+		grain := (hash2(x, y, salt) - 0.5) * 0.01
+		return pal.at(t + grain)
+	}
+}
+
+// plasma is domain-warped fractal value noise mapped onto the palette
+func plasma(rng *rand.Rand, pal palette, w, h int) shader {
+	freq := 2 + rng.Float64()*3
+	warp := 0.5 + rng.Float64()*1.5
+	salt := rng.Uint64()
+
+	unit := float64(max(w, h))
+
+	return func(x, y int) color.RGBA {
+		px, py := float64(x)/unit*freq, float64(y)/unit*freq
+
+		wx := fbm(px+1.7, py+9.2, salt+1)
+		wy := fbm(px+8.3, py+2.8, salt+2)
+		t := fbm(px+warp*wx, py+warp*wy, salt)
+
+		return pal.at(t)
+	}
+}
+
+// voronoi fills cells around random sites and darkens the borders between them
+func voronoi(rng *rand.Rand, pal palette, w, h int) shader {
+	n := 16 + rng.IntN(48)
+
+	type site struct {
+		x, y float64
+		c    color.RGBA
+	}
+
+	sites := make([]site, n)
+	for i := range sites {
+		sites[i] = site{
+			x: rng.Float64() * float64(w),
+			y: rng.Float64() * float64(h),
+			c: scale(pal.at(rng.Float64()), 0.85+rng.Float64()*0.3),
+		}
+	}
+
+	edge := float64(max(w, h)) / 400
+
+	return func(x, y int) color.RGBA {
+		px, py := float64(x), float64(y)
+		first, second := math.MaxFloat64, math.MaxFloat64
+		nearest := 0
+
+		for i, s := range sites {
+			d := math.Hypot(px-s.x, py-s.y)
+			switch {
+			case d < first:
+				second, first, nearest = first, d, i
+			case d < second:
+				second = d
+			}
+		}
+
+		border := clamp((second-first)/edge, 0, 1)
+		return scale(sites[nearest].c, 0.55+0.45*border)
+	}
+}
+
+// geometric is a low-poly triangle grid shaded along a palette gradient
+func geometric(rng *rand.Rand, pal palette, w, h int) shader {
+	cell := float64(max(w, h)) / float64(8+rng.IntN(16))
+	angle := rng.Float64() * 2 * math.Pi
+	dx, dy := math.Cos(angle), math.Sin(angle)
+	salt := rng.Uint64()
+
+	fw, fh := float64(w), float64(h)
+	diag := math.Hypot(fw, fh)
+
+	return func(x, y int) color.RGBA {
+		fx, fy := float64(x)/cell, float64(y)/cell
+		cx, cy := math.Floor(fx), math.Floor(fy)
+
+		// each grid cell is split along its diagonal into two triangles
+		tri := 0
+		if fx-cx+fy-cy > 1 {
+			tri = 1
+		}
+
+		centerX := (cx + 0.5) * cell
+		centerY := (cy + 0.5) * cell
+		t := ((centerX-fw/2)*dx+(centerY-fh/2)*dy)/diag + 0.5
+		jitter := hash2(int(cx)*2+tri, int(cy), salt)
+
+		return scale(pal.at(t+(jitter-0.5)*0.25), 0.85+jitter*0.3)
+	}
+}
+
+// fbm sums octaves of value noise into [0, 1]
+func fbm(x, y float64, salt uint64) float64 {
+	const octaves = 5
+
+	sum, amp, norm := 0.0, 0.5, 0.0
+	for i := 0; i < octaves; i++ {
+		sum += amp * valueNoise(x, y, salt+uint64(i))
+		norm += amp
+		x, y = x*2, y*2
+		amp /= 2
+	}
+	return sum / norm
+}
+
+func valueNoise(x, y float64, salt uint64) float64 {
+	x0, y0 := math.Floor(x), math.Floor(y)
+	ix, iy := int(x0), int(y0)
+	fx, fy := smooth(x-x0), smooth(y-y0)
+
+	a := hash2(ix, iy, salt)
+	b := hash2(ix+1, iy, salt)
+	c := hash2(ix, iy+1, salt)
+	d := hash2(ix+1, iy+1, salt)
+
+	top := a + (b-a)*fx
+	bottom := c + (d-c)*fx
+	return top + (bottom-top)*fy
+}
+
+func smooth(t float64) float64 {
+	return t * t * (3 - 2*t)
+}
+
+// hash2 maps lattice coordinates to a stable pseudo-random value in [0, 1)
+func hash2(x, y int, salt uint64) float64 {
+	h := uint64(x)*0x9e3779b97f4a7c15 ^ uint64(y)*0xc2b2ae3d27d4eb4f ^ salt
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return float64(h>>11) / float64(1<<53)
+}
@@ -0,0 +1,23 @@
+package generate
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:        Name,
+		Description: "procedural patterns seeded by the phrase",
+		Phrase:      searcher.PhraseAccepted,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.Pattern, "generate-pattern", "", "generate pattern: gradient, plasma, voronoi or geometric (default from phrase)")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewGenerator(log, opts)
+			}
+		},
+	})
+}
@@ -0,0 +1,145 @@
+package jsonapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "jsonapi"
+
+var (
+	ErrNoResults = errors.New("jsonapi: no usable results")
+)
+
+// Options configures the jsonapi searcher
+type Options struct {
+	// Template is a preset name or a path to a template json file
+	Template string
+	Paging   searcher.PageOptions
+}
+
+type JSONAPI struct {
+	log    zerolog.Logger
+	client http.Client
+	tmpl   Template
+	pager  *searcher.Pager
+}
+
+func NewJSONAPI(log zerolog.Logger, opts Options) (*JSONAPI, error) {
+	tmpl, err := LoadTemplate(opts.Template)
+	if err != nil {
+		return nil, err
+	}
+
+	return &JSONAPI{
+		log:   log.With().Str("component", "jsonapi").Logger(),
+		tmpl:  tmpl,
+		pager: searcher.NewPager(Name, opts.Paging),
+	}, nil
+}
+
+func (j *JSONAPI) IgnoresPhrase() bool { return !j.tmpl.UsesQuery() }
+
+func (j *JSONAPI) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, j, q, res)
+}
+
+func (j *JSONAPI) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	log := j.log.With().Str("op", "Candidates").Logger()
+
+	results, err := j.fetchResults(ctx, q, res)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]searcher.Candidate, 0, len(results))
+	for _, r := range results {
+		imgURL := lookupString(r, j.tmpl.Image)
+		if imgURL == "" {
+			continue
+		}
+
+		c := searcher.Candidate{
+			URL:      imgURL,
+			Width:    lookupInt(r, j.tmpl.Width),
+			Height:   lookupInt(r, j.tmpl.Height),
+			Metadata: j.tmpl.metadata(r),
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searcher.Download(ctx, &j.client, imgURL)
+			},
+		}
+		c.Metadata.Width, c.Metadata.Height = c.Width, c.Height
+		candidates = append(candidates, c)
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w among %d results for %s", ErrNoResults, len(results), q)
+	}
+	log.Info().Int("total", len(results)).Int("clean", len(candidates)).Msg("filtering complete")
+
+	rand.Shuffle(len(candidates), func(i, k int) { candidates[i], candidates[k] = candidates[k], candidates[i] })
+
+	return candidates, nil
+}
+
+func (j *JSONAPI) fetchResults(ctx context.Context, q string, res searcher.Resolution) ([]any, error) {
+	if !j.tmpl.Paged() {
+		return j.fetchPage(ctx, q, res, 1)
+	}
+
+	key := searcher.PageKey(q, j.tmpl.URL, res.String())
+	page := j.pager.Page(key)
+
+	results, err := j.fetchPage(ctx, q, res, page)
+	if err == nil && len(results) == 0 && page > 1 {
+		j.pager.Empty(key, page)
+		results, err = j.fetchPage(ctx, q, res, 1)
+	}
+	return results, err
+}
+
+func (j *JSONAPI) fetchPage(ctx context.Context, q string, res searcher.Resolution, page int) ([]any, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.tmpl.requestURL(q, res, page), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+	for k, v := range j.tmpl.headers(q, res, page) {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := j.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	var body any
+	dec := json.NewDecoder(resp.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&body); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	found, err := lookup(body, j.tmpl.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	results, ok := found.([]any)
+	if !ok {
+		return nil, fmt.Errorf("%w: %q is not an array", ErrInvalidPath, j.tmpl.Results)
+	}
+
+	return results, nil
+}
@@ -0,0 +1,24 @@
+package jsonapi
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "any json search api described by a template",
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.Template, "jsonapi-template", "", "jsonapi preset (pexels, pixabay, picsum) or path to a template file")
+
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				opts.Paging = env.Paging
+				return NewJSONAPI(log, opts)
+			}
+		},
+	})
+}
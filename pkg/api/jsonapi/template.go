@@ -0,0 +1,246 @@
+package jsonapi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+)
+
+var (
+	ErrNoTemplate  = errors.New("jsonapi: template is not set")
+	ErrInvalidPath = errors.New("jsonapi: path does not resolve")
+
+	envPlaceholder = regexp.MustCompile(`\{env:([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+	// presets are ready-made templates for common public apis
+	presets = map[string]Template{
+		"pexels": {
+			URL:     "https://api.pexels.com/v1/search?query={query}&per_page=80&page={page}",
+			Headers: map[string]string{"Authorization": "{env:PEXELS_API_KEY}"},
+			Results: "photos",
+			Image:   "src.original",
+			Width:   "width",
+			Height:  "height",
+			Metadata: MetadataPaths{
+				Title:     "alt",
+				Author:    "photographer",
+				AuthorURL: "photographer_url",
+				PageURL:   "url",
+			},
+		},
+		// largeImageURL is at most 1280px wide, fullHDURL and imageURL need full api access,
+		// so the original dimensions are not reported for it
+		"pixabay": {
+			URL:     "https://pixabay.com/api/?key={env:PIXABAY_API_KEY}&q={query}&image_type=photo&min_width={width}&min_height={height}&per_page=200&page={page}",
+			Results: "hits",
+			Image:   "largeImageURL",
+			Metadata: MetadataPaths{
+				Author:  "user",
+				PageURL: "pageURL",
+				Tags:    "tags",
+			},
+		},
+		"picsum": {
+			URL:     "https://picsum.photos/v2/list?limit=100&page={page}",
+			Results: "",
+			Image:   "download_url",
+			Width:   "width",
+			Height:  "height",
+			Metadata: MetadataPaths{
+				Author:  "author",
+				PageURL: "url",
+			},
+		},
+	}
+)
+
+// Template describes a "search -> list -> pick url" json api.
+//
+// URL and header values may contain {query}, {width}, {height}, {page} and {env:NAME} placeholders,
+// paths are dot-separated object keys and array indexes, e.g. "data.items" or "src.0.url",
+// an empty results path means the response itself is the array
+type Template struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Results string            `json:"results"`
+	Image   string            `json:"image"`
+	Width   string            `json:"width"`
+	Height  string            `json:"height"`
+	// Metadata holds optional paths describing each result
+	Metadata MetadataPaths `json:"metadata"`
+}
+
+// MetadataPaths are result paths of the searcher.Metadata fields, empty paths are skipped
+type MetadataPaths struct {
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	AuthorURL string `json:"author_url"`
+	PageURL   string `json:"page_url"`
+	License   string `json:"license"`
+	Tags      string `json:"tags"`
+}
+
+// metadata reads the metadata of a single result
+func (t Template) metadata(result any) searcher.Metadata {
+	paths := t.Metadata
+	meta := searcher.Metadata{
+		Source:    Name,
+		Title:     lookupString(result, paths.Title),
+		Author:    lookupString(result, paths.Author),
+		AuthorURL: lookupString(result, paths.AuthorURL),
+		PageURL:   lookupString(result, paths.PageURL),
+		License:   lookupString(result, paths.License),
+	}
+
+	if paths.Tags != "" {
+		found, _ := lookup(result, paths.Tags)
+		switch tags := found.(type) {
+		case []any:
+			for _, tag := range tags {
+				if s, ok := tag.(string); ok && s != "" {
+					meta.Tags = append(meta.Tags, s)
+				}
+			}
+		case string:
+			for _, tag := range strings.Split(tags, ",") {
+				if tag = strings.TrimSpace(tag); tag != "" {
+					meta.Tags = append(meta.Tags, tag)
+				}
+			}
+		}
+	}
+
+	return meta
+}
+
+// LoadTemplate returns a preset by name or reads a template from a json file
+func LoadTemplate(nameOrPath string) (Template, error) {
+	if nameOrPath == "" {
+		return Template{}, ErrNoTemplate
+	}
+
+	if t, ok := presets[nameOrPath]; ok {
+		return t, nil
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return Template{}, fmt.Errorf("jsonapi: read template: %w", err)
+	}
+
+	var t Template
+	if err := json.Unmarshal(data, &t); err != nil {
+		return Template{}, fmt.Errorf("jsonapi: decode template: %w", err)
+	}
+
+	if t.URL == "" || t.Image == "" {
+		return Template{}, fmt.Errorf("jsonapi: template %s must define url and image", nameOrPath)
+	}
+
+	return t, nil
+}
+
+// UsesQuery reports whether the request depends on the search phrase
+func (t Template) UsesQuery() bool {
+	return strings.Contains(t.URL, "{query}")
+}
+
+// Paged reports whether the request selects a 1-based result page
+func (t Template) Paged() bool {
+	return strings.Contains(t.URL, "{page}")
+}
+
+// requestURL expands the url placeholders, escaping values for the path or the query they land in
+func (t Template) requestURL(q string, res searcher.Resolution, page int) string {
+	path, query, hasQuery := strings.Cut(t.URL, "?")
+	u := expand(path, q, res, page, url.PathEscape)
+	if hasQuery {
+		u += "?" + expand(query, q, res, page, url.QueryEscape)
+	}
+	return u
+}
+
+func (t Template) headers(q string, res searcher.Resolution, page int) map[string]string {
+	h := make(map[string]string, len(t.Headers))
+	for k, v := range t.Headers {
+		h[k] = expand(v, q, res, page, func(s string) string { return s })
+	}
+	return h
+}
+
+// expand replaces the placeholders of s, the phrase and environment values are passed through escape
+func expand(s string, q string, res searcher.Resolution, page int, escape func(string) string) string {
+	s = envPlaceholder.ReplaceAllStringFunc(s, func(m string) string {
+		return escape(os.Getenv(envPlaceholder.FindStringSubmatch(m)[1]))
+	})
+
+	return strings.NewReplacer(
+		"{query}", escape(q),
+		"{width}", strconv.Itoa(res.Width),
+		"{height}", strconv.Itoa(res.Height),
+		"{page}", strconv.Itoa(page),
+	).Replace(s)
+}
+
+// lookup walks a decoded json value along a dot-separated path
+func lookup(v any, path string) (any, error) {
+	if path == "" {
+		return v, nil
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			next, ok := node[key]
+			if !ok {
+				return nil, fmt.Errorf("%w: missing key %q in %q", ErrInvalidPath, key, path)
+			}
+			v = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("%w: bad index %q in %q", ErrInvalidPath, key, path)
+			}
+			v = node[i]
+		default:
+			return nil, fmt.Errorf("%w: %q is not a container in %q", ErrInvalidPath, key, path)
+		}
+	}
+
+	return v, nil
+}
+
+func lookupString(v any, path string) string {
+	found, err := lookup(v, path)
+	if err != nil {
+		return ""
+	}
+
+	switch s := found.(type) {
+	case string:
+		return s
+	case json.Number:
+		return s.String()
+	default:
+		return ""
+	}
+}
+
+func lookupInt(v any, path string) int {
+	if path == "" {
+		return 0
+	}
+
+	n, err := strconv.ParseFloat(lookupString(v, path), 64)
+	if err != nil {
+		return 0
+	}
+	return int(n)
+}
@@ -0,0 +1,91 @@
+package jsonapi
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+func TestPresetsFollowResolution(t *testing.T) {
+	for name, tmpl := range presets {
+		if strings.Contains(tmpl.URL, "orientation=") {
+			t.Errorf("preset %s hard-codes the orientation: %s", name, tmpl.URL)
+		}
+		if !tmpl.Paged() {
+			t.Errorf("preset %s is not paged", name)
+		}
+	}
+}
+
+func TestPixabayPreset(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("PIXABAY_API_KEY", "secret")
+
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		_, _ = io.WriteString(w, `{"total": 1, "hits": [
+			{"largeImageURL": "https://cdn.example.org/a.jpg", "imageWidth": 6000, "imageHeight": 4000,
+			 "user": "someone", "pageURL": "https://pixabay.com/photos/a", "tags": "lake, mountains"},
+			{"previewURL": "https://cdn.example.org/preview.jpg"}
+		]}`)
+	}))
+	defer srv.Close()
+
+	j, err := NewJSONAPI(zerolog.Nop(), Options{Template: "pixabay"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	j.tmpl.URL = strings.Replace(j.tmpl.URL, "https://pixabay.com/api/", srv.URL+"/", 1)
+
+	candidates, err := j.Candidates(context.Background(), "alpine lake", searcher.Resolution{Width: 1920, Height: 1080})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, param := range []string{"key=secret", "q=alpine+lake", "min_width=1920", "min_height=1080", "page=1"} {
+		if !strings.Contains(query, param) {
+			t.Errorf("request %q lacks %s", query, param)
+		}
+	}
+
+	if len(candidates) != 1 {
+		t.Fatalf("got %d candidates, want 1", len(candidates))
+	}
+	c := candidates[0]
+	if c.URL != "https://cdn.example.org/a.jpg" || c.Known() {
+		t.Errorf("candidate = %s %dx%d, want the scaled image with an unknown size", c.URL, c.Width, c.Height)
+	}
+	if c.Metadata.Author != "someone" || !slices.Equal(c.Metadata.Tags, []string{"lake", "mountains"}) {
+		t.Errorf("Metadata = %+v", c.Metadata)
+	}
+}
+
+func TestRequestURLEscapes(t *testing.T) {
+	t.Setenv("JSONAPI_TEST_KEY", "a&b=c d")
+
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"query", "https://x.org/api?q={query}&key={env:JSONAPI_TEST_KEY}", "https://x.org/api?q=night+city%2Fneon&key=a%26b%3Dc+d"},
+		{"path", "https://x.org/search/{query}/{env:JSONAPI_TEST_KEY}?w={width}", "https://x.org/search/night%20city%2Fneon/a&b=c%20d?w=1920"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl := Template{URL: tt.url}
+			if got := tmpl.requestURL("night city/neon", searcher.Resolution{Width: 1920, Height: 1080}, 1); got != tt.want {
+				t.Errorf("requestURL = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,11 +2,16 @@ package local
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/labi-le/chiasma/pkg/api/searcher"
@@ -15,79 +20,219 @@ import (
 
 const Name = "local"
 
+var (
+	errSymlinkSkipped = errors.New("symlink skipped")
+)
+
+// Options configures the local library
+type Options struct {
+	Dir string
+	// Recursive descends into subdirectories
+	Recursive bool
+	// Include and Exclude are globs matched against the relative path and the file name
+	Include []string
+	Exclude []string
+	// FollowSymlinks resolves symlinked files and directories
+	FollowSymlinks bool
+	// IndexFile stores the library index, defaults to the user cache directory
+	IndexFile string
+	// Rescan is how long a scan is reused before the library is walked again, zero walks on every search
+	Rescan time.Duration
+}
+
 type Local struct {
-	dir string
-	log zerolog.Logger
+	opts Options
+	log  zerolog.Logger
+
+	mu      sync.Mutex
+	index   *index
+	entries []indexed
+	scanned time.Time
 }
 
-func NewLocal(log zerolog.Logger, dir string) *Local {
-	return &Local{
-		dir: dir,
-		log: log.With().Str("component", "local_fs").Logger(),
+func NewLocal(log zerolog.Logger, opts Options) (*Local, error) {
+	root, err := filepath.Abs(opts.Dir)
+	if err != nil {
+		return nil, fmt.Errorf("resolve %s: %w", opts.Dir, err)
 	}
+	opts.Dir = root
+
+	if opts.IndexFile == "" {
+		opts.IndexFile, err = defaultIndexFile(root)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Local{
+		opts:  opts,
+		log:   log.With().Str("component", "local_fs").Logger(),
+		index: loadIndex(opts.IndexFile, root),
+	}, nil
 }
 
-func (l *Local) Search(_ context.Context, q string, _ searcher.Resolution) (searcher.Image, error) {
+// AcceptsEmptyPhrase is true since an empty phrase picks from the whole library
+func (l *Local) AcceptsEmptyPhrase() bool { return true }
+
+func (l *Local) Search(_ context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
 	log := l.log.With().Str("op", "Search").Str("query", q).Logger()
 
-	entries, err := os.ReadDir(l.dir)
+	entries, err := l.refresh()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read directory %s: %w", l.dir, err)
+		return nil, err
+	}
+
+	terms := tokenize(q)
+	fits := true
+	candidates, partial := filter(entries, terms, res, fits)
+	if len(candidates) == 0 {
+		fits = false
+		candidates, partial = filter(entries, terms, res, fits)
+		if len(candidates) > 0 {
+			log.Warn().Int("closest", len(candidates)).Msg("no image fits the aspect ratio, falling back to the best-fitting ones")
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no local images found for query: %s", q)
+	}
+	if partial {
+		log.Warn().Int("closest", len(candidates)).Msg("nothing matches every term, falling back to the closest images")
 	}
 
-	queryTerms := strings.Fields(strings.ToLower(q))
-	var candidates []string
+	log.Debug().Int("total", len(entries)).Int("clean", len(candidates)).Msg("filtering complete")
 
-	for _, e := range entries {
-		if e.IsDir() {
+	// shuffling before the stable sort keeps equally good matches in random order
+	rand.Shuffle(len(candidates), func(i, j int) {
+		candidates[i], candidates[j] = candidates[j], candidates[i]
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		// images outside the tolerance are tried closest ratio first
+		return !fits && candidates[i].skew < candidates[j].skew
+	})
+
+	for _, c := range candidates {
+		f, err := os.Open(c.Path)
+		if err != nil {
+			log.Warn().Err(err).Str("path", c.Path).Msg("failed to open candidate")
 			continue
 		}
 
-		name := strings.ToLower(e.Name())
-		matched := true
-		for _, term := range queryTerms {
-			if !strings.Contains(name, term) {
-				matched = false
-				break
-			}
+		img, err := searcher.DetectSize(f)
+		if err != nil {
+			_ = f.Close()
+			log.Warn().Err(err).Str("path", c.Path).Msg("failed to detect image size")
+			continue
 		}
 
-		if matched {
-			candidates = append(candidates, filepath.Join(l.dir, e.Name()))
+		return searcher.WithMetadata(img, searcher.Metadata{
+			Source: Name,
+			Title:  filepath.Base(c.Path),
+			Tags:   c.Keywords,
+			Width:  c.Width,
+			Height: c.Height,
+		}), nil
+	}
+
+	return nil, fmt.Errorf("no valid images found among candidates")
+}
+
+type ranked struct {
+	entry
+	score float64
+	// skew is the distance to the target aspect ratio
+	skew float64
+}
+
+// filter keeps entries at least as large as res and, when fit is set, within the ratio tolerance.
+// It returns the entries matching every term, or else the closest ones and true
+func filter(entries []indexed, terms []string, res searcher.Resolution, fit bool) ([]ranked, bool) {
+	var matches, closest []ranked
+
+	for _, e := range entries {
+		if !e.valid() || e.Width < res.Width || e.Height < res.Height {
+			continue
+		}
+		if fit && !searcher.FitsAspectRatio(e.Width, e.Height, res, searcher.RatioTolerance) {
+			continue
+		}
+
+		s, matched := score(terms, tokenize(e.rel+" "+strings.Join(e.Keywords, " ")))
+		r := ranked{entry: e.entry, score: s, skew: ratioSkew(e.Width, e.Height, res)}
+		switch {
+		case matched:
+			matches = append(matches, r)
+		case s > 0:
+			closest = append(closest, r)
 		}
 	}
 
-	if len(candidates) == 0 {
-		return nil, fmt.Errorf("no local images found for query: %s", q)
+	if len(matches) == 0 && len(closest) > 0 {
+		return closest, true
 	}
+	return matches, false
+}
 
-	rand.Shuffle(len(candidates), func(i, j int) {
-		candidates[i], candidates[j] = candidates[j], candidates[i]
-	})
+// ratioSkew returns how far the aspect ratio of a w x h image is from res, zero for an unknown target
+func ratioSkew(w, h int, res searcher.Resolution) float64 {
+	if res.Width == 0 || res.Height == 0 {
+		return 0
+	}
+	return math.Abs(float64(w)/float64(h) - float64(res.Width)/float64(res.Height))
+}
 
-	for _, path := range candidates {
-		if err := l.validateImage(path); err == nil {
-			f, err := os.Open(path)
-			if err != nil {
-				log.Warn().Err(err).Str("path", path).Msg("failed to open candidate")
-				continue
-			}
-
-			img, err := searcher.DetectSize(f)
-			if err != nil {
-				_ = f.Close()
-				log.Warn().Err(err).Str("path", path).Msg("failed to detect image size")
-				continue
-			}
-
-			return img, nil
+type indexed struct {
+	entry
+	rel string
+}
+
+// refresh rescans the library once the last scan is older than Rescan,
+// re-inspecting only files whose size or mtime changed
+func (l *Local) refresh() ([]indexed, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.entries != nil && time.Since(l.scanned) < l.opts.Rescan {
+		return l.entries, nil
+	}
+
+	list, err := l.scan()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read directory %s: %w", l.opts.Dir, err)
+	}
+	files := list.files
+
+	seen := make(map[string]struct{}, len(files))
+	entries := make([]indexed, 0, len(files))
+	updated := 0
+
+	for _, f := range files {
+		seen[f.path] = struct{}{}
+
+		e, changed := l.index.update(f.path, f.info, list.sidecars)
+		if changed {
+			updated++
 		}
+		entries = append(entries, indexed{entry: e, rel: f.rel})
 	}
 
-	return nil, fmt.Errorf("no valid images found among candidates")
+	l.index.prune(seen)
+
+	if updated > 0 {
+		l.log.Debug().Int("files", len(files)).Int("updated", updated).Msg("index refreshed")
+	}
+
+	if err := l.index.save(); err != nil {
+		l.log.Warn().Err(err).Str("path", l.opts.IndexFile).Msg("failed to save index")
+	}
+
+	l.entries, l.scanned = entries, time.Now()
+	return entries, nil
 }
 
-func (l *Local) validateImage(path string) error {
+func validateImage(path string) error {
 	ext := filepath.Ext(path)
 	switch strings.ToLower(ext) {
 	case ".jpg", ".jpeg", ".png", ".webp", ".bmp":
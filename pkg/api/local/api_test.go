@@ -0,0 +1,85 @@
+package local
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	if err := png.Encode(f, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func newTestLocal(t *testing.T, dir string) *Local {
+	t.Helper()
+
+	l, err := NewLocal(zerolog.Nop(), Options{Dir: dir, IndexFile: filepath.Join(t.TempDir(), "index.json")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestSearchPrefersFittingRatio(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "wide.png"), 32, 18)
+	writePNG(t, filepath.Join(dir, "tall.png"), 18, 32)
+
+	l := newTestLocal(t, dir)
+	for i := 0; i < 10; i++ {
+		img, err := l.Search(context.Background(), "", searcher.Resolution{Width: 16, Height: 9})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = img.Close()
+
+		if w, h := img.Size(); w != 32 || h != 18 {
+			t.Fatalf("Size = %dx%d, want 32x18", w, h)
+		}
+	}
+}
+
+func TestSearchFallsBackToClosestRatio(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "square.png"), 40, 40)
+	writePNG(t, filepath.Join(dir, "tall.png"), 36, 64)
+
+	l := newTestLocal(t, dir)
+	for i := 0; i < 10; i++ {
+		img, err := l.Search(context.Background(), "", searcher.Resolution{Width: 32, Height: 8})
+		if err != nil {
+			t.Fatalf("Search with no image within the ratio tolerance: %v", err)
+		}
+		_ = img.Close()
+
+		if w, h := img.Size(); w != 40 || h != 40 {
+			t.Fatalf("Size = %dx%d, want the closest ratio 40x40", w, h)
+		}
+	}
+}
+
+func TestSearchKeepsMinimumSize(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "small.png"), 16, 9)
+
+	l := newTestLocal(t, dir)
+	if _, err := l.Search(context.Background(), "", searcher.Resolution{Width: 32, Height: 18}); err == nil {
+		t.Error("Search returned an image smaller than the resolution")
+	}
+}
@@ -0,0 +1,189 @@
+package local
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"image"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// indexVersion is bumped whenever entry fields change so stale indexes are rebuilt
+const indexVersion = 4
+
+type entry struct {
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	Width   int       `json:"width"`
+	Height  int       `json:"height"`
+
+	Keywords       []string  `json:"keywords,omitempty"`
+	SidecarModTime time.Time `json:"sidecar_mtime"`
+}
+
+// valid reports whether the file was decoded as an image
+func (e entry) valid() bool {
+	return e.Width > 0 && e.Height > 0
+}
+
+type index struct {
+	Version int              `json:"version"`
+	Root    string           `json:"root"`
+	Entries map[string]entry `json:"entries"`
+
+	file  string
+	dirty bool
+}
+
+func loadIndex(file string, root string) *index {
+	idx := &index{
+		Version: indexVersion,
+		Root:    root,
+		Entries: make(map[string]entry),
+		file:    file,
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return idx
+	}
+
+	var stored index
+	if err := json.Unmarshal(data, &stored); err != nil || stored.Version != indexVersion || stored.Root != root {
+		idx.dirty = true
+		return idx
+	}
+
+	if stored.Entries != nil {
+		idx.Entries = stored.Entries
+	}
+	return idx
+}
+
+// update refreshes the entry for path when the file or its listed sidecars changed, reporting whether it was rebuilt
+func (idx *index) update(path string, info iofs.FileInfo, listed map[string]iofs.FileInfo) (entry, bool) {
+	e, ok := idx.Entries[path]
+	fileChanged := !ok || e.Size != info.Size() || !e.ModTime.Equal(info.ModTime())
+
+	if fileChanged {
+		e = entry{
+			Path:    path,
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		}
+
+		// files that are not images are kept with zero dimensions so they are not inspected again
+		if validateImage(path) == nil {
+			if w, h, err := inspectImage(path); err == nil {
+				e.Width, e.Height = w, h
+			}
+		}
+	}
+
+	if !e.valid() {
+		if fileChanged {
+			idx.Entries[path] = e
+			idx.dirty = true
+		}
+		return e, fileChanged
+	}
+
+	// sidecars are edited by darktable/digiKam without touching the image itself
+	sidecars, sidecarTime := findSidecars(path, listed)
+	if !fileChanged && e.SidecarModTime.Equal(sidecarTime) {
+		return e, false
+	}
+
+	e.Keywords = readKeywords(path, sidecars)
+	e.SidecarModTime = sidecarTime
+
+	idx.Entries[path] = e
+	idx.dirty = true
+
+	return e, true
+}
+
+// findSidecars returns the listed sidecars of path and their latest modification time
+func findSidecars(path string, listed map[string]iofs.FileInfo) ([]string, time.Time) {
+	var (
+		found  []string
+		latest time.Time
+	)
+
+	for _, sidecar := range sidecarPaths(path) {
+		info, ok := listed[sidecar]
+		if !ok {
+			continue
+		}
+		found = append(found, sidecar)
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+
+	return found, latest
+}
+
+// prune drops entries of files that disappeared since the last scan
+func (idx *index) prune(seen map[string]struct{}) {
+	for path := range idx.Entries {
+		if _, ok := seen[path]; !ok {
+			delete(idx.Entries, path)
+			idx.dirty = true
+		}
+	}
+}
+
+func (idx *index) save() error {
+	if !idx.dirty {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(idx.file), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(idx)
+	if err != nil {
+		return fmt.Errorf("encode index: %w", err)
+	}
+
+	tmp := idx.file + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, idx.file); err != nil {
+		return err
+	}
+
+	idx.dirty = false
+	return nil
+}
+
+func inspectImage(path string) (int, int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer f.Close()
+
+	cfg, _, err := image.DecodeConfig(f)
+	if err != nil {
+		return 0, 0, err
+	}
+	return cfg.Width, cfg.Height, nil
+}
+
+func defaultIndexFile(root string) (string, error) {
+	cache, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve cache dir: %w", err)
+	}
+
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(cache, "chiasma", Name, fmt.Sprintf("%x.json", sum[:8])), nil
+}
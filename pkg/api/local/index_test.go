@@ -0,0 +1,64 @@
+package local
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRefreshReusesScan(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "a.png"), 32, 18)
+
+	l := newTestLocal(t, dir)
+	l.opts.Rescan = time.Hour
+
+	refresh := func() int {
+		entries, err := l.refresh()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(entries)
+	}
+
+	if n := refresh(); n != 1 {
+		t.Fatalf("indexed %d files, want 1", n)
+	}
+	writePNG(t, filepath.Join(dir, "b.png"), 32, 18)
+	if n := refresh(); n != 1 {
+		t.Errorf("indexed %d files within the rescan interval, want the cached 1", n)
+	}
+
+	l.scanned = time.Time{}
+	if n := refresh(); n != 2 {
+		t.Errorf("indexed %d files after the rescan interval, want 2", n)
+	}
+}
+
+func TestRefreshReadsListedSidecars(t *testing.T) {
+	dir := t.TempDir()
+	writePNG(t, filepath.Join(dir, "a.png"), 32, 18)
+
+	l := newTestLocal(t, dir)
+	l.opts.Include = []string{"*.png"}
+
+	keywords := func() []string {
+		entries, err := l.refresh()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return entries[0].Keywords
+	}
+
+	if got := keywords(); len(got) != 0 {
+		t.Fatalf("Keywords = %q without a sidecar", got)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "a.xmp"), []byte(testXMP), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if got := keywords(); !slices.Contains(got, "mountains") {
+		t.Errorf("Keywords = %q, want the sidecar keywords", got)
+	}
+}
@@ -0,0 +1,184 @@
+package local
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	scoreExact     = 1.0
+	scorePrefix    = 0.85
+	scoreSubstring = 0.7
+	scoreTypo      = 0.6
+
+	// minStemLen keeps stemming from reducing words to meaningless stubs
+	minStemLen = 3
+)
+
+var (
+	translit = map[rune]string{
+		'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "e", 'ж': "zh",
+		'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+		'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "h", 'ц': "ts",
+		'ч': "ch", 'ш': "sh", 'щ': "sch", 'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu",
+		'я': "ya", 'і': "i", 'ї': "yi", 'є': "ye", 'ґ': "g",
+	}
+
+	// suffixes are tried longest first, one per word
+	cyrillicSuffixes = []string{
+		"ями", "ами", "ого", "его", "ому", "ему", "ыми", "ими",
+		"ах", "ях", "ов", "ев", "ой", "ей", "ый", "ий", "ая", "яя", "ое", "ее", "ые", "ие", "ом", "ем", "ью",
+		"ы", "и", "а", "я", "е", "у", "ю", "о", "ь",
+	}
+	latinSuffixes = []string{"ies", "ing", "es", "ed", "s"}
+)
+
+// tokenize lowercases, splits on anything that is not a letter or digit, stems and transliterates to latin
+func tokenize(s string) []string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	tokens := make([]string, 0, len(fields))
+	for _, f := range fields {
+		tokens = append(tokens, transliterate(stem(f)))
+	}
+	return tokens
+}
+
+func stem(word string) string {
+	suffixes := latinSuffixes
+	if isCyrillic(word) {
+		suffixes = cyrillicSuffixes
+	}
+
+	for _, suf := range suffixes {
+		if !strings.HasSuffix(word, suf) {
+			continue
+		}
+		base := strings.TrimSuffix(word, suf)
+		if utf8.RuneCountInString(base) < minStemLen {
+			return word
+		}
+		if suf == "ies" {
+			return base + "y"
+		}
+		return base
+	}
+
+	return word
+}
+
+func isCyrillic(word string) bool {
+	for _, r := range word {
+		if unicode.Is(unicode.Cyrillic, r) {
+			return true
+		}
+	}
+	return false
+}
+
+func transliterate(word string) string {
+	if !isCyrillic(word) {
+		return word
+	}
+
+	var b strings.Builder
+	for _, r := range word {
+		if t, ok := translit[r]; ok {
+			b.WriteString(t)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// score rates how well doc matches the query terms, matched is true only when every term was found
+func score(terms []string, doc []string) (float64, bool) {
+	if len(terms) == 0 {
+		return scoreExact, true
+	}
+
+	total := 0.0
+	matched := true
+
+	for _, term := range terms {
+		best, ok := 0.0, false
+		for _, tok := range doc {
+			s, hit := termScore(term, tok)
+			if s > best {
+				best = s
+			}
+			ok = ok || hit
+		}
+
+		total += best
+		matched = matched && ok
+	}
+
+	return total / float64(len(terms)), matched
+}
+
+// termScore compares a single term with a document token, similarity is reported even for misses
+// so the closest files can be offered when nothing matches
+func termScore(term, tok string) (float64, bool) {
+	switch {
+	case term == tok:
+		return scoreExact, true
+	case len(term) >= minStemLen && strings.HasPrefix(tok, term):
+		return scorePrefix, true
+	case len(term) >= minStemLen && strings.Contains(tok, term):
+		return scoreSubstring, true
+	}
+
+	longest := max(utf8.RuneCountInString(term), utf8.RuneCountInString(tok))
+	if longest == 0 {
+		return 0, false
+	}
+
+	dist := levenshtein(term, tok)
+	similarity := 1 - float64(dist)/float64(longest)
+
+	if dist <= typoTolerance(term) {
+		return scoreTypo * similarity, true
+	}
+	return scoreTypo * similarity / 2, false
+}
+
+// typoTolerance allows one edit for medium words and two for long ones
+func typoTolerance(term string) int {
+	switch n := utf8.RuneCountInString(term); {
+	case n >= 8:
+		return 2
+	case n >= 4:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(rb)]
+}
@@ -0,0 +1,360 @@
+package local
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// metadataHeadSize bounds how much of a file is read looking for metadata,
+	// jpeg app segments and png text chunks sit in front of the pixel data
+	metadataHeadSize = 256 << 10
+	sidecarExt       = ".xmp"
+
+	tagImageDescription = 0x010e
+	tagXPKeywords       = 0x9c9e
+	tagXPSubject        = 0x9c9f
+
+	iptcResourceID  = 0x0404
+	iptcRecord      = 2
+	iptcObjectName  = 5
+	iptcKeywordSet  = 25
+	iptcCaption     = 120
+	iptcTagMarker   = 0x1c
+	jpegSOS         = 0xda
+	jpegEOI         = 0xd9
+	jpegMarkerStart = 0xff
+)
+
+var (
+	xmpStart = []byte("<x:xmpmeta")
+	xmpEnd   = []byte("</x:xmpmeta>")
+
+	exifHeader      = []byte("Exif\x00\x00")
+	photoshopHeader = []byte("Photoshop 3.0\x00")
+	resourceHeader  = []byte("8BIM")
+
+	// xmpProperties are the xmp properties whose values are searchable,
+	// matched by local name so dc, lr, digiKam and darktable variants are all covered
+	xmpProperties = map[string]struct{}{
+		"subject":             {},
+		"hierarchicalSubject": {},
+		"TagsList":            {},
+		"Keywords":            {},
+		"title":               {},
+		"description":         {},
+	}
+)
+
+// readKeywords collects keywords from the file itself and its sidecars
+func readKeywords(path string, sidecars []string) []string {
+	var words []string
+
+	if embedded, err := readEmbedded(path); err == nil {
+		words = append(words, embedded...)
+	}
+
+	for _, sidecar := range sidecars {
+		data, err := os.ReadFile(sidecar)
+		if err != nil {
+			continue
+		}
+		words = append(words, parseXMP(data)...)
+	}
+
+	return normalizeKeywords(words)
+}
+
+// sidecarPaths lists the darktable (image.jpg.xmp) and digiKam/lightroom (image.xmp) sidecar names
+func sidecarPaths(path string) []string {
+	return []string{
+		path + sidecarExt,
+		strings.TrimSuffix(path, filepath.Ext(path)) + sidecarExt,
+	}
+}
+
+func readEmbedded(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	head := make([]byte, metadataHeadSize)
+	n, err := io.ReadFull(f, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	var words []string
+	if bytes.HasPrefix(head, []byte{jpegMarkerStart, 0xd8}) {
+		words = append(words, jpegKeywords(head)...)
+	}
+	if packet := findXMP(head); packet != nil {
+		words = append(words, parseXMP(packet)...)
+	}
+
+	return words, nil
+}
+
+// jpegKeywords walks the jpeg app segments for exif and iptc metadata
+func jpegKeywords(data []byte) []string {
+	var words []string
+
+	for i := 2; i+4 <= len(data); {
+		if data[i] != jpegMarkerStart {
+			break
+		}
+
+		marker := data[i+1]
+		switch {
+		case marker == jpegMarkerStart:
+			i++
+			continue
+		case marker == jpegSOS, marker == jpegEOI:
+			return words
+		case marker >= 0xd0 && marker <= 0xd8, marker == 0x01:
+			i += 2
+			continue
+		}
+
+		length := int(binary.BigEndian.Uint16(data[i+2 : i+4]))
+		if length < 2 || i+2+length > len(data) {
+			break
+		}
+		segment := data[i+4 : i+2+length]
+
+		switch {
+		case bytes.HasPrefix(segment, exifHeader):
+			words = append(words, exifKeywords(segment[len(exifHeader):])...)
+		case bytes.HasPrefix(segment, photoshopHeader):
+			words = append(words, photoshopKeywords(segment[len(photoshopHeader):])...)
+		}
+
+		i += 2 + length
+	}
+
+	return words
+}
+
+// exifKeywords reads ImageDescription and the windows XPKeywords/XPSubject tags from IFD0
+func exifKeywords(tiff []byte) []string {
+	if len(tiff) < 8 {
+		return nil
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return nil
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return nil
+	}
+
+	var words []string
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+
+	for n := 0; n < count; n++ {
+		off := ifd + 2 + n*12
+		if off+12 > len(tiff) {
+			break
+		}
+
+		tag := order.Uint16(tiff[off : off+2])
+		if tag != tagImageDescription && tag != tagXPKeywords && tag != tagXPSubject {
+			continue
+		}
+
+		size := int(order.Uint32(tiff[off+4 : off+8]))
+		valueOff := off + 8
+		if size > 4 {
+			valueOff = int(order.Uint32(tiff[off+8 : off+12]))
+		}
+		if size <= 0 || valueOff+size > len(tiff) {
+			continue
+		}
+		value := tiff[valueOff : valueOff+size]
+
+		if tag == tagImageDescription {
+			words = append(words, strings.TrimRight(string(value), "\x00 "))
+			continue
+		}
+
+		// XP tags are utf-16le, keywords are separated by semicolons
+		words = append(words, strings.Split(decodeUTF16LE(value), ";")...)
+	}
+
+	return words
+}
+
+func decodeUTF16LE(b []byte) string {
+	u := make([]uint16, 0, len(b)/2)
+	for i := 0; i+1 < len(b); i += 2 {
+		u = append(u, binary.LittleEndian.Uint16(b[i:]))
+	}
+	return strings.TrimRight(string(utf16.Decode(u)), "\x00")
+}
+
+// photoshopKeywords finds the iptc resource among photoshop image resource blocks
+func photoshopKeywords(data []byte) []string {
+	for i := 0; i+len(resourceHeader)+2 < len(data); {
+		if !bytes.HasPrefix(data[i:], resourceHeader) {
+			return nil
+		}
+		i += len(resourceHeader)
+
+		id := binary.BigEndian.Uint16(data[i : i+2])
+		i += 2
+
+		// pascal name padded to an even length, including the length byte
+		nameLen := int(data[i]) + 1
+		i += nameLen + nameLen%2
+
+		if i+4 > len(data) {
+			return nil
+		}
+		size := int(binary.BigEndian.Uint32(data[i : i+4]))
+		i += 4
+
+		if i+size > len(data) {
+			return nil
+		}
+		if id == iptcResourceID {
+			return iptcKeywords(data[i : i+size])
+		}
+
+		i += size + size%2
+	}
+
+	return nil
+}
+
+func iptcKeywords(data []byte) []string {
+	var words []string
+
+	for i := 0; i+5 <= len(data); {
+		if data[i] != iptcTagMarker {
+			break
+		}
+
+		record, dataset := data[i+1], data[i+2]
+		size := int(binary.BigEndian.Uint16(data[i+3 : i+5]))
+		i += 5
+
+		// extended datasets are never used for text fields
+		if size&0x8000 != 0 || i+size > len(data) {
+			break
+		}
+
+		if record == iptcRecord {
+			switch dataset {
+			case iptcObjectName, iptcKeywordSet, iptcCaption:
+				words = append(words, string(data[i:i+size]))
+			}
+		}
+
+		i += size
+	}
+
+	return words
+}
+
+func findXMP(data []byte) []byte {
+	start := bytes.Index(data, xmpStart)
+	if start < 0 {
+		return nil
+	}
+
+	end := bytes.Index(data[start:], xmpEnd)
+	if end < 0 {
+		return nil
+	}
+
+	return data[start : start+end+len(xmpEnd)]
+}
+
+// parseXMP returns the values of keyword, title and description properties,
+// whether written as rdf:li items, as plain text (pdf:Keywords) or as attributes of rdf:Description
+func parseXMP(data []byte) []string {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	dec.Strict = false
+
+	var (
+		words    []string
+		stack    []string
+		inTarget int
+	)
+
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return words
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Name.Local)
+			if _, ok := xmpProperties[t.Name.Local]; ok {
+				inTarget++
+			}
+			for _, attr := range t.Attr {
+				if _, ok := xmpProperties[attr.Name.Local]; ok && attr.Name.Space != "xmlns" {
+					words = append(words, attr.Value)
+				}
+			}
+		case xml.EndElement:
+			if len(stack) == 0 {
+				continue
+			}
+			if _, ok := xmpProperties[stack[len(stack)-1]]; ok {
+				inTarget--
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if inTarget == 0 || len(stack) == 0 {
+				continue
+			}
+			_, plain := xmpProperties[stack[len(stack)-1]]
+			if text := strings.TrimSpace(string(t)); text != "" && (plain || stack[len(stack)-1] == "li") {
+				words = append(words, text)
+			}
+		}
+	}
+}
+
+// normalizeKeywords lowercases, splits tag hierarchies (places|alps, places/alps) and removes duplicates
+func normalizeKeywords(words []string) []string {
+	seen := make(map[string]struct{}, len(words))
+	out := make([]string, 0, len(words))
+
+	for _, w := range words {
+		for _, part := range strings.FieldsFunc(w, func(r rune) bool { return r == '|' || r == '/' }) {
+			part = strings.ToLower(strings.TrimSpace(part))
+			if part == "" {
+				continue
+			}
+			if _, ok := seen[part]; ok {
+				continue
+			}
+			seen[part] = struct{}{}
+			out = append(out, part)
+		}
+	}
+
+	return out
+}
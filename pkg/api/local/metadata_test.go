@@ -0,0 +1,172 @@
+package local
+
+import (
+	"bytes"
+	"encoding/binary"
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"unicode/utf16"
+)
+
+const testXMP = `<x:xmpmeta xmlns:x="adobe:ns:meta/">
+ <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+  <rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:lr="http://ns.adobe.com/lightroom/1.0/">
+   <dc:subject><rdf:Bag><rdf:li>Mountains</rdf:li><rdf:li>snow</rdf:li></rdf:Bag></dc:subject>
+   <lr:hierarchicalSubject><rdf:Bag><rdf:li>places|alps</rdf:li></rdf:Bag></lr:hierarchicalSubject>
+   <dc:creator><rdf:Seq><rdf:li>someone</rdf:li></rdf:Seq></dc:creator>
+  </rdf:Description>
+ </rdf:RDF>
+</x:xmpmeta>`
+
+// segment wraps payload into a jpeg marker segment
+func segment(marker byte, payload []byte) []byte {
+	b := []byte{jpegMarkerStart, marker, 0, 0}
+	binary.BigEndian.PutUint16(b[2:], uint16(len(payload)+2))
+	return append(b, payload...)
+}
+
+// exifPayload builds a little-endian tiff with ImageDescription and XPKeywords in IFD0
+func exifPayload(description string, keywords string) []byte {
+	desc := append([]byte(description), 0)
+	var xp []byte
+	for _, u := range utf16.Encode([]rune(keywords)) {
+		xp = binary.LittleEndian.AppendUint16(xp, u)
+	}
+	xp = append(xp, 0, 0)
+
+	const ifd = 8
+	values := ifd + 2 + 2*12 + 4
+
+	tiff := []byte("II\x2a\x00")
+	tiff = binary.LittleEndian.AppendUint32(tiff, ifd)
+	tiff = binary.LittleEndian.AppendUint16(tiff, 2)
+
+	entry := func(tag uint16, typ uint16, size int, offset int) {
+		tiff = binary.LittleEndian.AppendUint16(tiff, tag)
+		tiff = binary.LittleEndian.AppendUint16(tiff, typ)
+		tiff = binary.LittleEndian.AppendUint32(tiff, uint32(size))
+		tiff = binary.LittleEndian.AppendUint32(tiff, uint32(offset))
+	}
+	entry(tagImageDescription, 2, len(desc), values)
+	entry(tagXPKeywords, 1, len(xp), values+len(desc))
+	tiff = binary.LittleEndian.AppendUint32(tiff, 0)
+
+	tiff = append(tiff, desc...)
+	tiff = append(tiff, xp...)
+	return append(slices.Clone(exifHeader), tiff...)
+}
+
+// photoshopPayload builds an iptc resource with the given keywords
+func photoshopPayload(keywords ...string) []byte {
+	var iptc []byte
+	for _, k := range keywords {
+		iptc = append(iptc, iptcTagMarker, iptcRecord, iptcKeywordSet)
+		iptc = binary.BigEndian.AppendUint16(iptc, uint16(len(k)))
+		iptc = append(iptc, k...)
+	}
+
+	b := slices.Clone(photoshopHeader)
+	b = append(b, resourceHeader...)
+	b = binary.BigEndian.AppendUint16(b, iptcResourceID)
+	b = append(b, 0, 0)
+	b = binary.BigEndian.AppendUint32(b, uint32(len(iptc)))
+	return append(b, iptc...)
+}
+
+func testJPEG() []byte {
+	b := []byte{jpegMarkerStart, 0xd8}
+	b = append(b, segment(0xe1, exifPayload("Lake at dawn", "lake;dawn"))...)
+	b = append(b, segment(0xed, photoshopPayload("reflection", "Water"))...)
+	b = append(b, segment(0xe1, []byte("http://ns.adobe.com/xap/1.0/\x00"+testXMP))...)
+	return append(b, jpegMarkerStart, jpegSOS, 0, 2)
+}
+
+func TestJPEGKeywords(t *testing.T) {
+	got := jpegKeywords(testJPEG())
+	want := []string{"Lake at dawn", "lake", "dawn", "reflection", "Water"}
+	if !slices.Equal(got, want) {
+		t.Errorf("jpegKeywords = %q, want %q", got, want)
+	}
+}
+
+func TestJPEGKeywordsTruncated(t *testing.T) {
+	data := testJPEG()
+	for n := 0; n < len(data); n++ {
+		_ = jpegKeywords(data[:n])
+	}
+}
+
+func TestParseXMP(t *testing.T) {
+	got := parseXMP([]byte(testXMP))
+	want := []string{"Mountains", "snow", "places|alps"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseXMP = %q, want %q", got, want)
+	}
+}
+
+func TestParseXMPPlainValues(t *testing.T) {
+	data := `<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:pdf="http://ns.adobe.com/pdf/1.3/"
+  dc:title="Frozen lake" dc:format="image/jpeg">
+  <pdf:Keywords>winter, ice</pdf:Keywords>
+  <dc:creator>someone</dc:creator>
+</rdf:Description>
+</rdf:RDF></x:xmpmeta>`
+
+	got := parseXMP([]byte(data))
+	want := []string{"Frozen lake", "winter, ice"}
+	if !slices.Equal(got, want) {
+		t.Errorf("parseXMP = %q, want %q", got, want)
+	}
+}
+
+func TestFindXMP(t *testing.T) {
+	data := testJPEG()
+	packet := findXMP(data)
+	if !bytes.HasPrefix(packet, xmpStart) || !bytes.HasSuffix(packet, xmpEnd) {
+		t.Errorf("findXMP = %q", packet)
+	}
+	if findXMP(data[:len(data)-len(xmpEnd)-10]) != nil {
+		t.Error("findXMP returned an unterminated packet")
+	}
+}
+
+func TestNormalizeKeywords(t *testing.T) {
+	got := normalizeKeywords([]string{"Places|Alps", "places/alps", " Snow ", "", "snow"})
+	want := []string{"places", "alps", "snow"}
+	if !slices.Equal(got, want) {
+		t.Errorf("normalizeKeywords = %q, want %q", got, want)
+	}
+}
+
+func TestReadKeywords(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "lake.jpg")
+	if err := os.WriteFile(path, testJPEG(), 0600); err != nil {
+		t.Fatal(err)
+	}
+	sidecar := filepath.Join(dir, "lake.xmp")
+	if err := os.WriteFile(sidecar, []byte(`<x:xmpmeta xmlns:x="adobe:ns:meta/"><rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+<rdf:Description xmlns:digiKam="http://www.digikam.org/ns/1.0/"><digiKam:TagsList><rdf:Seq><rdf:li>Trips/Norway</rdf:li></rdf:Seq></digiKam:TagsList></rdf:Description>
+</rdf:RDF></x:xmpmeta>`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := os.Stat(sidecar)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sidecars, _ := findSidecars(path, map[string]iofs.FileInfo{sidecar: info})
+	if !slices.Equal(sidecars, []string{sidecar}) {
+		t.Fatalf("findSidecars = %q, want %q", sidecars, sidecar)
+	}
+
+	got := readKeywords(path, sidecars)
+	want := []string{"lake at dawn", "lake", "dawn", "reflection", "water", "mountains", "snow", "places", "alps", "trips", "norway"}
+	if !slices.Equal(got, want) {
+		t.Errorf("readKeywords = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,35 @@
+package local
+
+import (
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:        Name,
+		Description: "images from a local directory",
+		Phrase:      searcher.PhraseAccepted,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.Dir, "local-dir", "", "local library directory (default --save-dir)")
+			fs.BoolVar(&opts.Recursive, "local-recursive", false, "scan the local library recursively")
+			fs.StringSliceVar(&opts.Include, "local-include", nil, "globs of local files to include")
+			fs.StringSliceVar(&opts.Exclude, "local-exclude", nil, "globs of local files and directories to exclude")
+			fs.BoolVar(&opts.FollowSymlinks, "local-follow-symlinks", false, "follow symlinks in the local library")
+			fs.StringVar(&opts.IndexFile, "local-index", "", "local library index file (default in the user cache dir)")
+			fs.DurationVar(&opts.Rescan, "local-rescan", 10*time.Minute, "how long a scan of the local library is reused, 0 scans on every search")
+
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				opts := opts
+				if opts.Dir == "" {
+					opts.Dir = env.SaveDir
+				}
+				return NewLocal(log, opts)
+			}
+		},
+	})
+}
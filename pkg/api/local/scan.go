@@ -0,0 +1,118 @@
+package local
+
+import (
+	iofs "io/fs"
+	"os"
+	"path/filepath"
+)
+
+type scanned struct {
+	path string
+	rel  string
+	info iofs.FileInfo
+}
+
+// listing is the result of a scan, sidecars are listed whatever the include globs
+// so the index finds them without statting every possible sidecar path
+type listing struct {
+	files    []scanned
+	sidecars map[string]iofs.FileInfo
+}
+
+// scan lists files under the library root honouring recursion, globs and symlink settings
+func (l *Local) scan() (listing, error) {
+	root, err := filepath.EvalSymlinks(l.opts.Dir)
+	if err != nil {
+		return listing{}, err
+	}
+
+	list := listing{sidecars: make(map[string]iofs.FileInfo)}
+	visited := map[string]struct{}{root: {}}
+
+	err = l.walk(l.opts.Dir, "", visited, &list)
+	return list, err
+}
+
+func (l *Local) walk(dir string, rel string, visited map[string]struct{}, list *listing) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		relPath := filepath.Join(rel, e.Name())
+
+		if matchAny(l.opts.Exclude, relPath) {
+			continue
+		}
+
+		info, err := l.resolve(path, e)
+		if err != nil {
+			l.log.Debug().Err(err).Str("path", path).Msg("skipping entry")
+			continue
+		}
+
+		if info.IsDir() {
+			if !l.opts.Recursive {
+				continue
+			}
+
+			// resolved directories are tracked so symlink loops are walked only once
+			real, err := filepath.EvalSymlinks(path)
+			if err != nil {
+				continue
+			}
+			if _, ok := visited[real]; ok {
+				continue
+			}
+			visited[real] = struct{}{}
+
+			if err := l.walk(path, relPath, visited, list); err != nil {
+				l.log.Warn().Err(err).Str("path", path).Msg("failed to read directory")
+			}
+			continue
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if filepath.Ext(path) == sidecarExt {
+			list.sidecars[path] = info
+		}
+		if len(l.opts.Include) > 0 && !matchAny(l.opts.Include, relPath) {
+			continue
+		}
+
+		list.files = append(list.files, scanned{path: path, rel: relPath, info: info})
+	}
+
+	return nil
+}
+
+// resolve returns the file info of e, following symlinks only when enabled
+func (l *Local) resolve(path string, e os.DirEntry) (iofs.FileInfo, error) {
+	if e.Type()&os.ModeSymlink == 0 {
+		return e.Info()
+	}
+
+	if !l.opts.FollowSymlinks {
+		return nil, errSymlinkSkipped
+	}
+
+	return os.Stat(path)
+}
+
+// matchAny checks patterns against both the relative path and the base name
+func matchAny(patterns []string, rel string) bool {
+	base := filepath.Base(rel)
+	for _, p := range patterns {
+		if ok, _ := filepath.Match(p, rel); ok {
+			return true
+		}
+		if ok, _ := filepath.Match(p, base); ok {
+			return true
+		}
+	}
+	return false
+}
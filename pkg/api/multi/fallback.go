@@ -0,0 +1,108 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Separator = ","
+
+var (
+	ErrAllSourcesFailed = errors.New("all sources failed")
+)
+
+// Fallback tries its sources in order and returns the first image found
+type Fallback struct {
+	log     zerolog.Logger
+	sources []Source
+	timeout time.Duration
+}
+
+func NewFallback(log zerolog.Logger, timeout time.Duration, sources ...Source) *Fallback {
+	return &Fallback{
+		log:     log.With().Str("component", "fallback").Logger(),
+		sources: sources,
+		timeout: timeout,
+	}
+}
+
+// IgnoresPhrase is true only when none of the sources needs a phrase
+func (f *Fallback) IgnoresPhrase() bool {
+	for _, src := range f.sources {
+		if !searcher.IgnoresPhrase(src.Searcher) {
+			return false
+		}
+	}
+	return true
+}
+
+// AcceptsEmptyPhrase is true when at least one source can run without a phrase
+func (f *Fallback) AcceptsEmptyPhrase() bool {
+	for _, src := range f.sources {
+		if !searcher.RequiresPhrase(src.Searcher) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fallback) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	log := f.log.With().Str("op", "Search").Logger()
+
+	errs := make([]error, 0, len(f.sources))
+	for _, src := range f.sources {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		img, err := searchWithTimeout(ctx, src, q, res, f.timeout)
+		if err != nil {
+			log.Warn().Err(err).Str("source", src.Name).Msg("source failed, trying next")
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+			continue
+		}
+
+		log.Info().Str("source", src.Name).Msg("image found")
+		return img, nil
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrAllSourcesFailed, errors.Join(errs...))
+}
+
+// Candidates returns the candidates of the first source that has any,
+// followed by a candidate listing the remaining sources in case all of them are rejected
+func (f *Fallback) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	log := f.log.With().Str("op", "Candidates").Logger()
+
+	errs := make([]error, 0, len(f.sources))
+	for i, src := range f.sources {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		candidates, err := candidatesWithTimeout(ctx, src, q, res, f.timeout)
+		if err != nil {
+			log.Warn().Err(err).Str("source", src.Name).Msg("source failed, trying next")
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+			continue
+		}
+
+		log.Info().Str("source", src.Name).Int("candidates", len(candidates)).Msg("candidates found")
+		if rest := f.sources[i+1:]; len(rest) > 0 {
+			next := &Fallback{log: f.log, sources: rest, timeout: f.timeout}
+			candidates = append(candidates, searcher.Candidate{
+				Fallback: func(ctx context.Context) ([]searcher.Candidate, error) {
+					return next.Candidates(ctx, q, res)
+				},
+			})
+		}
+		return candidates, nil
+	}
+
+	return nil, fmt.Errorf("%w: %w", ErrAllSourcesFailed, errors.Join(errs...))
+}
@@ -0,0 +1,192 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+var (
+	ErrNoWeights = errors.New("mix: at least one source must have a positive weight")
+)
+
+// Weighted is a mix member, Phrase overrides the search phrase for this source only
+type Weighted struct {
+	Source
+	Weight int
+	Phrase string
+}
+
+// Mix picks a source per search by weight and never uses the same source more than MaxRepeat times in a row
+type Mix struct {
+	log       zerolog.Logger
+	sources   []Weighted
+	timeout   time.Duration
+	maxRepeat int
+
+	mu     sync.Mutex
+	last   int
+	streak int
+}
+
+func NewMix(log zerolog.Logger, timeout time.Duration, maxRepeat int, sources ...Weighted) (*Mix, error) {
+	active := make([]Weighted, 0, len(sources))
+	for _, src := range sources {
+		if src.Weight > 0 {
+			active = append(active, src)
+		}
+	}
+
+	if len(active) == 0 {
+		return nil, ErrNoWeights
+	}
+
+	return &Mix{
+		log:       log.With().Str("component", "mix").Logger(),
+		sources:   active,
+		timeout:   timeout,
+		maxRepeat: maxRepeat,
+		last:      -1,
+	}, nil
+}
+
+// IgnoresPhrase is true when every source either ignores the phrase or has its own
+func (m *Mix) IgnoresPhrase() bool {
+	for _, src := range m.sources {
+		if src.Phrase == "" && !searcher.IgnoresPhrase(src.Searcher) {
+			return false
+		}
+	}
+	return true
+}
+
+// AcceptsEmptyPhrase is true when at least one source can run without the shared phrase
+func (m *Mix) AcceptsEmptyPhrase() bool {
+	for _, src := range m.sources {
+		if src.Phrase != "" || !searcher.RequiresPhrase(src.Searcher) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Mix) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	var img searcher.Image
+	err := m.each(ctx, q, "Search", func(idx int, phrase string) error {
+		var err error
+		if img, err = searchWithTimeout(ctx, m.sources[idx].Source, phrase, res, m.timeout); err == nil {
+			m.record(idx)
+		}
+		return err
+	})
+	return img, err
+}
+
+// Candidates lists the candidates of a source chosen by weight,
+// the repeat streak counts the source once one of them is accepted rather than on every listing
+func (m *Mix) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	var candidates []searcher.Candidate
+	err := m.each(ctx, q, "Candidates", func(idx int, phrase string) error {
+		var err error
+		candidates, err = candidatesWithTimeout(ctx, m.sources[idx].Source, phrase, res, m.timeout)
+		for i := range candidates {
+			accepted := candidates[i].Accepted
+			candidates[i].Accepted = func() {
+				m.record(idx)
+				if accepted != nil {
+					accepted()
+				}
+			}
+		}
+		return err
+	})
+	return candidates, err
+}
+
+// each calls try with sources chosen by weight until one succeeds
+func (m *Mix) each(ctx context.Context, q string, op string, try func(idx int, phrase string) error) error {
+	log := m.log.With().Str("op", op).Logger()
+
+	remaining := m.eligible()
+	errs := make([]error, 0, len(remaining))
+
+	for len(remaining) > 0 {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		pos := pick(m.sources, remaining)
+		idx := remaining[pos]
+		remaining = slices.Delete(remaining, pos, pos+1)
+
+		src := m.sources[idx]
+		phrase := q
+		if src.Phrase != "" {
+			phrase = src.Phrase
+		}
+
+		log.Debug().Str("source", src.Name).Str("phrase", phrase).Msg("source chosen")
+
+		if err := try(idx, phrase); err != nil {
+			log.Warn().Err(err).Str("source", src.Name).Msg("source failed, choosing another")
+			errs = append(errs, fmt.Errorf("%s: %w", src.Name, err))
+			continue
+		}
+
+		log.Info().Str("source", src.Name).Msg("source used")
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrAllSourcesFailed, errors.Join(errs...))
+}
+
+// eligible lists source indexes, leaving out the last one once it hit the repeat limit
+func (m *Mix) eligible() []int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	idx := make([]int, 0, len(m.sources))
+	for i := range m.sources {
+		if len(m.sources) > 1 && m.maxRepeat > 0 && i == m.last && m.streak >= m.maxRepeat {
+			continue
+		}
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+func (m *Mix) record(idx int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if idx == m.last {
+		m.streak++
+		return
+	}
+	m.last = idx
+	m.streak = 1
+}
+
+// pick returns a position in idx chosen proportionally to the source weights
+func pick(sources []Weighted, idx []int) int {
+	total := 0
+	for _, i := range idx {
+		total += sources[i].Weight
+	}
+
+	n := rand.IntN(total)
+	for pos, i := range idx {
+		n -= sources[i].Weight
+		if n < 0 {
+			return pos
+		}
+	}
+	return len(idx) - 1
+}
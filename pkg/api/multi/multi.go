@@ -0,0 +1,126 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+)
+
+var (
+	ErrEmptyResult = errors.New("source returned no image")
+)
+
+// Source is a searcher together with the name it was selected by
+type Source struct {
+	Name string
+	searcher.Searcher
+}
+
+// timedImage stops the source timeout once the body is handed over and releases it on close
+type timedImage struct {
+	searcher.Image
+	cancel context.CancelFunc
+}
+
+func (t timedImage) Close() error {
+	defer t.cancel()
+	return t.Image.Close()
+}
+
+// searchWithTimeout bounds the time a source may take to produce an image,
+// the image body itself is not subject to the timeout
+func searchWithTimeout(ctx context.Context, src Source, q string, res searcher.Resolution, timeout time.Duration) (searcher.Image, error) {
+	return withTimeout(ctx, src.Name, timeout, func(ctx context.Context) (searcher.Image, error) {
+		return src.Search(ctx, q, res)
+	})
+}
+
+// candidatesWithTimeout lists the candidates of src within the timeout and bounds each fetch the same way,
+// a plain searcher becomes a single candidate that runs the search once fetched
+func candidatesWithTimeout(ctx context.Context, src Source, q string, res searcher.Resolution, timeout time.Duration) ([]searcher.Candidate, error) {
+	cs, ok := src.Searcher.(searcher.CandidateSearcher)
+	if !ok {
+		return []searcher.Candidate{{
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searchWithTimeout(ctx, src, q, res, timeout)
+			},
+		}}, nil
+	}
+
+	listCtx := ctx
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		listCtx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	candidates, err := cs.Candidates(listCtx, q, res)
+	switch {
+	case err != nil && errors.Is(listCtx.Err(), context.DeadlineExceeded):
+		return nil, fmt.Errorf("timed out after %s: %w", timeout, err)
+	case err != nil:
+		return nil, err
+	case len(candidates) == 0:
+		return nil, ErrEmptyResult
+	}
+
+	for i := range candidates {
+		fetch := candidates[i].Fetch
+		candidates[i].Fetch = func(ctx context.Context) (searcher.Image, error) {
+			return withTimeout(ctx, src.Name, timeout, fetch)
+		}
+		if candidates[i].Metadata.Source == "" {
+			candidates[i].Metadata.Source = src.Name
+		}
+	}
+
+	return candidates, nil
+}
+
+// withTimeout runs fetch with a context cancelled after timeout unless an image was returned by then,
+// the returned image releases the context on close
+func withTimeout(ctx context.Context, name string, timeout time.Duration, fetch func(ctx context.Context) (searcher.Image, error)) (searcher.Image, error) {
+	if timeout <= 0 {
+		img, err := fetch(ctx)
+		if err == nil && img == nil {
+			return nil, ErrEmptyResult
+		}
+		return img, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	timer := time.AfterFunc(timeout, cancel)
+
+	img, err := fetch(ctx)
+	timedOut := !timer.Stop()
+
+	switch {
+	case err != nil && timedOut:
+		cancel()
+		return nil, fmt.Errorf("timed out after %s: %w", timeout, err)
+	case err != nil:
+		cancel()
+		return nil, err
+	case img == nil:
+		cancel()
+		return nil, ErrEmptyResult
+	}
+
+	return describe(timedImage{Image: img, cancel: cancel}, img, name), nil
+}
+
+// describe forwards the metadata of the image timed wraps, naming the source that produced it,
+// images without metadata stay undescribed so no sidecar is written for them
+func describe(timed searcher.Image, img searcher.Image, source string) searcher.Image {
+	meta, ok := searcher.MetadataOf(img)
+	if !ok {
+		return timed
+	}
+	if meta.Source == "" {
+		meta.Source = source
+	}
+	return searcher.WithMetadata(timed, meta)
+}
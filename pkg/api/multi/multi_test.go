@@ -0,0 +1,227 @@
+package multi
+
+import (
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+var errNotFound = errors.New("not found")
+
+type phraseSearcher struct{}
+
+func (phraseSearcher) Search(context.Context, string, searcher.Resolution) (searcher.Image, error) {
+	return nil, errNotFound
+}
+
+type phraselessSearcher struct{ phraseSearcher }
+
+func (phraselessSearcher) IgnoresPhrase() bool { return true }
+
+type optionalSearcher struct{ phraseSearcher }
+
+func (optionalSearcher) AcceptsEmptyPhrase() bool { return true }
+
+func TestFallbackIgnoresPhrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Source
+		want    bool
+	}{
+		{"phraseless", []Source{{Name: "a", Searcher: phraselessSearcher{}}, {Name: "b", Searcher: phraselessSearcher{}}}, true},
+		{"mixed", []Source{{Name: "a", Searcher: phraselessSearcher{}}, {Name: "b", Searcher: phraseSearcher{}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallback(zerolog.Nop(), time.Second, tt.sources...)
+			if got := f.IgnoresPhrase(); got != tt.want {
+				t.Errorf("IgnoresPhrase = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMixIgnoresPhrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Weighted
+		want    bool
+	}{
+		{"phraseless", []Weighted{{Source: Source{Name: "a", Searcher: phraselessSearcher{}}, Weight: 1}}, true},
+		{"own phrase", []Weighted{{Source: Source{Name: "a", Searcher: phraseSearcher{}}, Weight: 1, Phrase: "nebula"}}, true},
+		{"needs phrase", []Weighted{
+			{Source: Source{Name: "a", Searcher: phraselessSearcher{}}, Weight: 1},
+			{Source: Source{Name: "b", Searcher: phraseSearcher{}}, Weight: 1},
+		}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMix(zerolog.Nop(), time.Second, 0, tt.sources...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.IgnoresPhrase(); got != tt.want {
+				t.Errorf("IgnoresPhrase = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackAcceptsEmptyPhrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Source
+		want    bool
+	}{
+		{"optional", []Source{{Name: "a", Searcher: phraseSearcher{}}, {Name: "b", Searcher: optionalSearcher{}}}, true},
+		{"phraseless", []Source{{Name: "a", Searcher: phraselessSearcher{}}}, true},
+		{"required", []Source{{Name: "a", Searcher: phraseSearcher{}}, {Name: "b", Searcher: phraseSearcher{}}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallback(zerolog.Nop(), time.Second, tt.sources...)
+			if got := f.AcceptsEmptyPhrase(); got != tt.want {
+				t.Errorf("AcceptsEmptyPhrase = %v, want %v", got, tt.want)
+			}
+			if got := searcher.RequiresPhrase(f); got == tt.want {
+				t.Errorf("RequiresPhrase = %v, want %v", got, !tt.want)
+			}
+		})
+	}
+}
+
+func TestMixAcceptsEmptyPhrase(t *testing.T) {
+	tests := []struct {
+		name    string
+		sources []Weighted
+		want    bool
+	}{
+		{"optional", []Weighted{{Source: Source{Name: "a", Searcher: optionalSearcher{}}, Weight: 1}}, true},
+		{"own phrase", []Weighted{{Source: Source{Name: "a", Searcher: phraseSearcher{}}, Weight: 1, Phrase: "nebula"}}, true},
+		{"required", []Weighted{{Source: Source{Name: "a", Searcher: phraseSearcher{}}, Weight: 1}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewMix(zerolog.Nop(), time.Second, 0, tt.sources...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.AcceptsEmptyPhrase(); got != tt.want {
+				t.Errorf("AcceptsEmptyPhrase = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFallbackSearchReportsEverySource(t *testing.T) {
+	f := NewFallback(zerolog.Nop(), time.Second,
+		Source{Name: "a", Searcher: phraseSearcher{}},
+		Source{Name: "b", Searcher: phraseSearcher{}},
+	)
+
+	_, err := f.Search(context.Background(), "q", searcher.Resolution{})
+	if !errors.Is(err, ErrAllSourcesFailed) || !errors.Is(err, errNotFound) {
+		t.Errorf("Search error = %v", err)
+	}
+}
+
+// imageSearcher returns img from every search
+type imageSearcher struct{ img searcher.Image }
+
+func (s imageSearcher) Search(context.Context, string, searcher.Resolution) (searcher.Image, error) {
+	return s.img, nil
+}
+
+type nopImage struct{}
+
+func (nopImage) Read([]byte) (int, error) { return 0, io.EOF }
+func (nopImage) Close() error             { return nil }
+func (nopImage) Size() (int, int)         { return 1, 1 }
+
+func TestFallbackForwardsMetadata(t *testing.T) {
+	tests := []struct {
+		name      string
+		img       searcher.Image
+		described bool
+		want      searcher.Metadata
+	}{
+		{"undescribed", nopImage{}, false, searcher.Metadata{}},
+		{"named", searcher.WithMetadata(nopImage{}, searcher.Metadata{Title: "t"}), true, searcher.Metadata{Source: "a", Title: "t"}},
+		{"own source", searcher.WithMetadata(nopImage{}, searcher.Metadata{Source: "b"}), true, searcher.Metadata{Source: "b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := NewFallback(zerolog.Nop(), time.Second, Source{Name: "a", Searcher: imageSearcher{tt.img}})
+			img, err := f.Search(context.Background(), "q", searcher.Resolution{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer img.Close()
+
+			meta, ok := searcher.MetadataOf(img)
+			if ok != tt.described {
+				t.Fatalf("described = %v, want %v", ok, tt.described)
+			}
+			if meta.Source != tt.want.Source || meta.Title != tt.want.Title {
+				t.Errorf("Metadata = %+v, want %+v", meta, tt.want)
+			}
+		})
+	}
+}
+
+// listSearcher lists a single candidate with its id
+type listSearcher struct{ id string }
+
+func (s listSearcher) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, s, q, res)
+}
+
+func (s listSearcher) Candidates(context.Context, string, searcher.Resolution) ([]searcher.Candidate, error) {
+	return []searcher.Candidate{{ID: s.id, Fetch: func(context.Context) (searcher.Image, error) {
+		return nopImage{}, nil
+	}}}, nil
+}
+
+func TestMixCountsAcceptedCandidates(t *testing.T) {
+	m, err := NewMix(zerolog.Nop(), time.Second, 1,
+		Weighted{Source: Source{Name: "a", Searcher: listSearcher{"a"}}, Weight: 1},
+		Weighted{Source: Source{Name: "b", Searcher: listSearcher{"b"}}, Weight: 1},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// retries within one update list again without using anything
+	var chosen searcher.Candidate
+	for range 10 {
+		candidates, err := m.Candidates(context.Background(), "q", searcher.Resolution{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		chosen = candidates[0]
+	}
+	if len(m.eligible()) != 2 {
+		t.Fatal("listing candidates counted towards the repeat limit")
+	}
+
+	chosen.Accept()
+	for range 10 {
+		candidates, err := m.Candidates(context.Background(), "q", searcher.Resolution{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if candidates[0].ID == chosen.ID {
+			t.Fatalf("source %s chosen again past the repeat limit", chosen.ID)
+		}
+	}
+}
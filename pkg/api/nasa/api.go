@@ -17,7 +17,9 @@ import (
 const Name = "nasa"
 
 const (
-	nasaSearchURL = "https://images-api.nasa.gov/search?q=%s&media_type=image"
+	nasaSearchURL = "https://images-api.nasa.gov/search?q=%s&media_type=image&page=%d"
+	// pageSize is the fixed number of items per search page
+	pageSize = 100
 )
 
 var (
@@ -36,18 +38,24 @@ var (
 type Nasa struct {
 	log    zerolog.Logger
 	client http.Client
+	pager  *searcher.Pager
 }
 
-func NewNasa(log zerolog.Logger) *Nasa {
+func NewNasa(log zerolog.Logger, paging searcher.PageOptions) *Nasa {
 	return &Nasa{
-		log: log.With().Str("component", "nasa").Logger(),
+		log:   log.With().Str("component", "nasa").Logger(),
+		pager: searcher.NewPager(Name, paging),
 	}
 }
 
 type nasaData struct {
-	Title       string   `json:"title"`
-	Keywords    []string `json:"keywords"`
-	Description string   `json:"description"`
+	NasaID           string   `json:"nasa_id"`
+	Title            string   `json:"title"`
+	Keywords         []string `json:"keywords"`
+	Description      string   `json:"description"`
+	Photographer     string   `json:"photographer"`
+	SecondaryCreator string   `json:"secondary_creator"`
+	Center           string   `json:"center"`
 }
 
 type nasaItem struct {
@@ -57,71 +65,103 @@ type nasaItem struct {
 
 type nasaSearchResult struct {
 	Collection struct {
-		Items []nasaItem `json:"items"`
+		Items    []nasaItem `json:"items"`
+		Metadata struct {
+			TotalHits int `json:"total_hits"`
+		} `json:"metadata"`
 	} `json:"collection"`
 }
 
 func (n *Nasa) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
-	log := n.log.With().Str("op", "Search").Logger()
+	return searcher.SearchCandidates(ctx, n, q, res)
+}
+
+func (n *Nasa) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	log := n.log.With().Str("op", "Candidates").Logger()
 
 	items, err := n.fetchSearchResults(ctx, q)
 	if err != nil {
 		return nil, err
 	}
 
-	candidates := n.filterCandidates(items)
+	clean := n.filterCandidates(items)
 
-	if len(candidates) == 0 {
+	if len(clean) == 0 {
 		log.Warn().Msg("all images were filtered out as technical, falling back to raw results")
-		candidates = items
+		clean = items
 	} else {
-		log.Info().Int("total", len(items)).Int("clean", len(candidates)).Msg("filtering complete")
+		log.Info().Int("total", len(items)).Int("clean", len(clean)).Msg("filtering complete")
 	}
 
-	const maxRetries = 10
-	shuffled := make([]nasaItem, len(candidates))
-	copy(shuffled, candidates)
-	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+	rand.Shuffle(len(clean), func(i, j int) { clean[i], clean[j] = clean[j], clean[i] })
 
-	for i := 0; i < len(shuffled) && i < maxRetries; i++ {
-		if ctx.Err() != nil {
-			return nil, ctx.Err()
+	candidates := make([]searcher.Candidate, 0, len(clean))
+	for _, item := range clean {
+		meta := item.metadata()
+		var id string
+		if len(item.Data) > 0 {
+			id = item.Data[0].NasaID
 		}
 
-		selected := shuffled[i]
-		log.Debug().Str("href", selected.Href).Int("attempt", i+1).Msg("trying candidate")
+		candidates = append(candidates, searcher.Candidate{
+			ID:       id,
+			URL:      item.Href,
+			Metadata: meta,
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return n.fetch(ctx, item.Href, res)
+			},
+		})
+	}
 
-		imgURL, err := n.resolveImageURL(ctx, selected.Href)
-		if err != nil {
-			if ctx.Err() != nil {
-				return nil, ctx.Err()
-			}
-			log.Warn().Err(err).Msg("failed to resolve image url")
-			continue
-		}
+	return candidates, nil
+}
 
-		img, err := n.downloadImage(ctx, imgURL)
-		if err != nil {
-			if ctx.Err() != nil {
-				return nil, ctx.Err()
-			}
-			log.Warn().Err(err).Msg("failed to download image")
-			continue
-		}
+// fetch resolves the asset manifest at href and downloads the best image,
+// the size is only known afterwards so panoramas and strips are rejected here
+func (n *Nasa) fetch(ctx context.Context, href string, res searcher.Resolution) (searcher.Image, error) {
+	imgURL, err := n.resolveImageURL(ctx, href)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve image url: %w", err)
+	}
 
-		w, h := img.Size()
-		if isAspectRatioBad(w, h, res.Width, res.Height) {
-			img.Close()
-			log.Debug().
-				Int("w", w).Int("h", h).
-				Msg("image rejected: bad aspect ratio (panorama/strip detected)")
-			continue
-		}
+	img, err := searcher.Download(ctx, &n.client, imgURL)
+	if err != nil {
+		return nil, err
+	}
+
+	w, h := img.Size()
+	if isAspectRatioBad(w, h, res.Width, res.Height) {
+		img.Close()
+		n.log.Debug().
+			Int("w", w).Int("h", h).
+			Msg("image rejected: bad aspect ratio (panorama/strip detected)")
+		return nil, fmt.Errorf("bad aspect ratio: %dx%d", w, h)
+	}
+
+	return img, nil
+}
 
-		return img, nil
+func (i nasaItem) metadata() searcher.Metadata {
+	meta := searcher.Metadata{Source: Name}
+	if len(i.Data) == 0 {
+		return meta
 	}
 
-	return nil, fmt.Errorf("failed to find suitable image after %d attempts", maxRetries)
+	data := i.Data[0]
+	meta.Title = data.Title
+	meta.Description = data.Description
+	meta.Tags = data.Keywords
+	meta.Author = data.Photographer
+	if meta.Author == "" {
+		meta.Author = data.SecondaryCreator
+	}
+	if meta.Author == "" && data.Center != "" {
+		meta.Author = "NASA " + data.Center
+	}
+	if data.NasaID != "" {
+		meta.PageURL = "https://images.nasa.gov/details/" + url.PathEscape(data.NasaID)
+	}
+	return meta
 }
 
 func isAspectRatioBad(imgW, imgH, targetW, targetH int) bool {
@@ -203,7 +243,27 @@ func containsStopWord(text string) (bool, string) {
 }
 
 func (n *Nasa) fetchSearchResults(ctx context.Context, q string) ([]nasaItem, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(nasaSearchURL, url.QueryEscape(q)), nil)
+	key := searcher.PageKey(q)
+	page := n.pager.Page(key)
+
+	items, err := n.fetchPage(ctx, q, key, page)
+	if err == nil && len(items) == 0 && page > 1 {
+		n.pager.Empty(key, page)
+		items, err = n.fetchPage(ctx, q, key, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, fmt.Errorf("no results for %s", q)
+	}
+
+	return items, nil
+}
+
+func (n *Nasa) fetchPage(ctx context.Context, q string, key string, page int) ([]nasaItem, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, fmt.Sprintf(nasaSearchURL, url.QueryEscape(q), page), nil)
 	if err != nil {
 		return nil, fmt.Errorf("create req: %w", err)
 	}
@@ -223,8 +283,8 @@ func (n *Nasa) fetchSearchResults(ctx context.Context, q string) ([]nasaItem, er
 		return nil, fmt.Errorf("decode json: %w", err)
 	}
 
-	if len(res.Collection.Items) == 0 {
-		return nil, fmt.Errorf("no results for %s", q)
+	if hits := res.Collection.Metadata.TotalHits; hits > 0 {
+		n.pager.SetLast(key, (hits+pageSize-1)/pageSize)
 	}
 
 	return res.Collection.Items, nil
@@ -250,25 +310,6 @@ func (n *Nasa) resolveImageURL(ctx context.Context, href string) (string, error)
 	return findBestImage(assets), nil
 }
 
-func (n *Nasa) downloadImage(ctx context.Context, url string) (searcher.Image, error) {
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("create img req: %w", err)
-	}
-
-	resp, err := n.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("download img: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		resp.Body.Close()
-		return nil, fmt.Errorf("img status: %d", resp.StatusCode)
-	}
-
-	return searcher.DetectSize(resp.Body)
-}
-
 func findBestImage(urls []string) string {
 	for _, u := range urls {
 		if strings.Contains(u, "~orig.jpg") {
@@ -0,0 +1,193 @@
+package nasa
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const NameAPOD = "nasa-apod"
+
+const (
+	apodURL = "https://api.nasa.gov/planetary/apod"
+
+	// apodBatch is how many random entries are requested at once
+	apodBatch = 10
+)
+
+// APODOptions configures the astronomy picture of the day searcher
+type APODOptions struct {
+	// APIKey is an api.nasa.gov key, DEMO_KEY is used when empty
+	APIKey string
+	// Random picks random dates instead of today
+	Random bool
+}
+
+type APOD struct {
+	log    zerolog.Logger
+	client http.Client
+	opts   APODOptions
+}
+
+func NewAPOD(log zerolog.Logger, opts APODOptions) *APOD {
+	if opts.APIKey == "" {
+		opts.APIKey = "DEMO_KEY"
+	}
+
+	return &APOD{
+		log:  log.With().Str("component", "nasa-apod").Logger(),
+		opts: opts,
+	}
+}
+
+type apodEntry struct {
+	Date        string `json:"date"`
+	Title       string `json:"title"`
+	Explanation string `json:"explanation"`
+	Copyright   string `json:"copyright"`
+	MediaType   string `json:"media_type"`
+	URL         string `json:"url"`
+	HDURL       string `json:"hdurl"`
+}
+
+func (a apodEntry) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:      NameAPOD,
+		Title:       a.Title,
+		Description: a.Explanation,
+		Author:      strings.Join(strings.Fields(a.Copyright), " "),
+	}
+	// apod pages are named apYYMMDD.html after the entry date
+	if date := strings.ReplaceAll(a.Date, "-", ""); len(date) == 8 {
+		meta.PageURL = fmt.Sprintf("https://apod.nasa.gov/apod/ap%s.html", date[2:])
+	}
+	return meta
+}
+
+func (a *APOD) IgnoresPhrase() bool { return true }
+
+func (a *APOD) Search(ctx context.Context, _ string, res searcher.Resolution) (searcher.Image, error) {
+	log := a.log.With().Str("op", "Search").Logger()
+
+	if !a.opts.Random {
+		entries, err := a.fetch(ctx, 0)
+		if err != nil {
+			return nil, err
+		}
+
+		img, err := a.pick(ctx, entries, res)
+		if err == nil {
+			return img, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		log.Info().Err(err).Msg("today's picture is unusable, falling back to random dates")
+	}
+
+	entries, err := a.fetch(ctx, apodBatch)
+	if err != nil {
+		return nil, err
+	}
+
+	return a.pick(ctx, entries, res)
+}
+
+func (a *APOD) pick(ctx context.Context, entries []apodEntry, res searcher.Resolution) (searcher.Image, error) {
+	log := a.log.With().Str("op", "pick").Logger()
+
+	rand.Shuffle(len(entries), func(i, j int) { entries[i], entries[j] = entries[j], entries[i] })
+
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		if entry.MediaType != "image" {
+			log.Debug().Str("date", entry.Date).Str("media_type", entry.MediaType).Msg("candidate rejected")
+			continue
+		}
+
+		// explanations are long prose that routinely mention "data" or "map", so only the title is inspected
+		if found, word := containsStopWord(strings.ToLower(entry.Title)); found {
+			log.Debug().Str("title", entry.Title).Str("reject_reason", "title_"+word).Msg("candidate rejected")
+			continue
+		}
+
+		imgURL := entry.HDURL
+		if imgURL == "" {
+			imgURL = entry.URL
+		}
+
+		img, err := searcher.Download(ctx, &a.client, imgURL)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Warn().Err(err).Str("date", entry.Date).Msg("failed to download image")
+			continue
+		}
+
+		w, h := img.Size()
+		if isAspectRatioBad(w, h, res.Width, res.Height) {
+			img.Close()
+			log.Debug().
+				Int("w", w).Int("h", h).
+				Msg("image rejected: bad aspect ratio (panorama/strip detected)")
+			continue
+		}
+
+		return searcher.WithMetadata(img, entry.metadata()), nil
+	}
+
+	return nil, fmt.Errorf("no suitable apod image among %d entries", len(entries))
+}
+
+// fetch returns today's entry when count is zero, otherwise count random entries
+func (a *APOD) fetch(ctx context.Context, count int) ([]apodEntry, error) {
+	params := url.Values{}
+	params.Set("api_key", a.opts.APIKey)
+	params.Set("thumbs", "false")
+	if count > 0 {
+		params.Set("count", strconv.Itoa(count))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apodURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do apod req: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	if count == 0 {
+		var entry apodEntry
+		if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+			return nil, fmt.Errorf("decode json: %w", err)
+		}
+		return []apodEntry{entry}, nil
+	}
+
+	var entries []apodEntry
+	if err := json.NewDecoder(resp.Body).Decode(&entries); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	return entries, nil
+}
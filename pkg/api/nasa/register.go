@@ -0,0 +1,37 @@
+package nasa
+
+import (
+	"os"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "nasa image and video library search",
+		NeedsNetwork: true,
+		Bind: func(*flag.FlagSet) searcher.Factory {
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				return NewNasa(log, env.Paging), nil
+			}
+		},
+	})
+	searcher.Register(searcher.Registration{
+		Name:         NameAPOD,
+		Description:  "nasa astronomy picture of the day",
+		Phrase:       searcher.PhraseIgnored,
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts APODOptions
+			fs.StringVar(&opts.APIKey, "nasa-key", os.Getenv("NASA_API_KEY"), "api.nasa.gov key for nasa-apod (default DEMO_KEY)")
+			fs.BoolVar(&opts.Random, "apod-random", false, "use a random date instead of today for nasa-apod")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewAPOD(log, opts), nil
+			}
+		},
+	})
+}
@@ -0,0 +1,327 @@
+package reddit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "reddit"
+
+const (
+	baseURL   = "https://www.reddit.com"
+	userAgent = "linux:chiasma:v1 (wallpaper manager)"
+	limit     = 100
+)
+
+var (
+	ErrNoSubreddits = errors.New("reddit: no subreddits configured")
+	ErrNoPosts      = errors.New("reddit: no suitable posts")
+
+	// resolutionTag matches title tags such as [3840x2160] or (1920 × 1080)
+	resolutionTag = regexp.MustCompile(`[\[(]\s*(\d{3,5})\s*[xX×*]\s*(\d{3,5})\s*[\])]`)
+)
+
+// Options configures the reddit searcher
+type Options struct {
+	Subreddits []string
+	// Sort is top, hot or new
+	Sort string
+	// Time is the window for top: hour, day, week, month, year or all
+	Time string
+	// NSFW allows posts marked over 18
+	NSFW   bool
+	Paging searcher.PageOptions
+}
+
+type Reddit struct {
+	log    zerolog.Logger
+	client http.Client
+	opts   Options
+	pager  *searcher.Pager
+}
+
+func NewReddit(log zerolog.Logger, opts Options) (*Reddit, error) {
+	if len(opts.Subreddits) == 0 {
+		return nil, ErrNoSubreddits
+	}
+	if opts.Sort == "" {
+		opts.Sort = "top"
+	}
+	if opts.Time == "" {
+		opts.Time = "week"
+	}
+
+	return &Reddit{
+		log:    log.With().Str("component", "reddit").Logger(),
+		client: searcher.UserAgentClient(userAgent),
+		opts:   opts,
+		pager:  searcher.NewPager(Name, opts.Paging),
+	}, nil
+}
+
+type post struct {
+	ID        string `json:"id"`
+	Title     string `json:"title"`
+	Author    string `json:"author"`
+	Subreddit string `json:"subreddit"`
+	URL       string `json:"url"`
+	Domain    string `json:"domain"`
+	Permalink string `json:"permalink"`
+	PostHint  string `json:"post_hint"`
+	IsGallery bool   `json:"is_gallery"`
+	IsVideo   bool   `json:"is_video"`
+	Over18    bool   `json:"over_18"`
+	Preview   struct {
+		Images []struct {
+			Source struct {
+				Width  int `json:"width"`
+				Height int `json:"height"`
+			} `json:"source"`
+		} `json:"images"`
+	} `json:"preview"`
+}
+
+type listing struct {
+	Data struct {
+		// After is the cursor of the next page, empty on the last one
+		After    string `json:"after"`
+		Children []struct {
+			Data post `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+type candidate struct {
+	post
+	imageURL string
+	w, h     int
+}
+
+func (c candidate) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:  Name,
+		Title:   c.Title,
+		Author:  c.post.Author,
+		PageURL: "https://www.reddit.com" + c.Permalink,
+		Width:   c.w,
+		Height:  c.h,
+	}
+	if c.post.Author != "" {
+		meta.AuthorURL = "https://www.reddit.com/user/" + c.post.Author
+	}
+	if c.Subreddit != "" {
+		meta.Tags = []string{"r/" + c.Subreddit}
+	}
+	return meta
+}
+
+// AcceptsEmptyPhrase is true since an empty phrase reads the plain listing
+func (r *Reddit) AcceptsEmptyPhrase() bool { return true }
+
+func (r *Reddit) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, r, q, res)
+}
+
+func (r *Reddit) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	log := r.log.With().Str("op", "Candidates").Logger()
+
+	posts, err := r.fetchListing(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+
+	clean := r.filterCandidates(posts, res)
+	if len(clean) == 0 {
+		return nil, fmt.Errorf("%w among %d posts for %s", ErrNoPosts, len(posts), q)
+	}
+	log.Info().Int("total", len(posts)).Int("clean", len(clean)).Msg("filtering complete")
+
+	rand.Shuffle(len(clean), func(i, j int) { clean[i], clean[j] = clean[j], clean[i] })
+
+	candidates := make([]searcher.Candidate, 0, len(clean))
+	for _, c := range clean {
+		candidates = append(candidates, searcher.Candidate{
+			ID:       c.ID,
+			URL:      c.imageURL,
+			Width:    c.w,
+			Height:   c.h,
+			Metadata: c.metadata(),
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searcher.Download(ctx, &r.client, c.imageURL)
+			},
+		})
+	}
+
+	return candidates, nil
+}
+
+// filterCandidates drops posts that are not direct images or are known to be too small before downloading anything
+func (r *Reddit) filterCandidates(posts []post, res searcher.Resolution) []candidate {
+	candidates := make([]candidate, 0, len(posts))
+
+	for _, p := range posts {
+		reason := r.inspect(p)
+
+		imgURL, ok := directImageURL(p)
+		if reason == "" && !ok {
+			reason = "not_direct_image"
+		}
+
+		w, h := postResolution(p)
+		if reason == "" && w > 0 && h > 0 {
+			if w < res.Width || h < res.Height {
+				reason = "too_small"
+			} else if !searcher.FitsAspectRatio(w, h, res, searcher.RatioTolerance) {
+				reason = "aspect_ratio"
+			}
+		}
+
+		if reason != "" {
+			r.log.Debug().Str("title", p.Title).Str("reject_reason", reason).Msg("candidate rejected")
+			continue
+		}
+
+		candidates = append(candidates, candidate{post: p, imageURL: imgURL, w: w, h: h})
+	}
+
+	return candidates
+}
+
+func (r *Reddit) inspect(p post) string {
+	switch {
+	case p.IsGallery:
+		return "gallery"
+	case p.IsVideo, strings.HasSuffix(p.PostHint, "video"):
+		return "video"
+	case p.Over18 && !r.opts.NSFW:
+		return "nsfw"
+	default:
+		return ""
+	}
+}
+
+// fetchListing reads one listing page, pages past the first are only reachable through the cursor of the one before
+func (r *Reddit) fetchListing(ctx context.Context, q string) ([]post, error) {
+	subs := strings.Join(r.opts.Subreddits, "+")
+	key := searcher.PageKey(q, subs, r.opts.Sort, r.opts.Time)
+	page, after := r.pager.Cursor(key)
+
+	posts, err := r.fetchPage(ctx, q, subs, key, page, after)
+	if err == nil && len(posts) == 0 && page > 1 {
+		r.pager.Empty(key, page)
+		posts, err = r.fetchPage(ctx, q, subs, key, 1, "")
+	}
+	return posts, err
+}
+
+func (r *Reddit) fetchPage(ctx context.Context, q string, subs string, key string, page int, after string) ([]post, error) {
+	params := url.Values{}
+	params.Set("t", r.opts.Time)
+	params.Set("limit", strconv.Itoa(limit))
+	params.Set("raw_json", "1")
+	if after != "" {
+		params.Set("after", after)
+	}
+
+	endpoint := fmt.Sprintf("%s/r/%s/%s.json", baseURL, subs, r.opts.Sort)
+	if q != "" {
+		endpoint = fmt.Sprintf("%s/r/%s/search.json", baseURL, subs)
+		params.Set("q", q)
+		params.Set("restrict_sr", "1")
+		params.Set("sort", r.opts.Sort)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do listing: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	var l listing
+	if err := json.NewDecoder(resp.Body).Decode(&l); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+	if len(l.Data.Children) > 0 {
+		r.pager.SetCursor(key, page+1, l.Data.After)
+	}
+
+	posts := make([]post, 0, len(l.Data.Children))
+	for _, c := range l.Data.Children {
+		posts = append(posts, c.Data)
+	}
+
+	return posts, nil
+}
+
+// directImageURL resolves i.redd.it and imgur posts to a downloadable image
+func directImageURL(p post) (string, bool) {
+	u, err := url.Parse(p.URL)
+	if err != nil {
+		return "", false
+	}
+
+	ext := strings.ToLower(path.Ext(u.Path))
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+
+	switch host {
+	case "i.redd.it", "i.imgur.com":
+		return p.URL, isImageExt(ext)
+	case "imgur.com", "m.imgur.com":
+		id := strings.Trim(u.Path, "/")
+		if id == "" || strings.Contains(id, "/") {
+			// albums (/a/...) and galleries (/gallery/...) are not single images
+			return "", false
+		}
+		if ext == "" {
+			return "https://i.imgur.com/" + id + ".jpg", true
+		}
+		return "https://i.imgur.com/" + id, isImageExt(ext)
+	default:
+		return p.URL, isImageExt(ext)
+	}
+}
+
+func isImageExt(ext string) bool {
+	switch ext {
+	case ".jpg", ".jpeg", ".png":
+		return true
+	}
+	return false
+}
+
+// postResolution reads the size from a title tag, falling back to the preview source
+func postResolution(p post) (int, int) {
+	if m := resolutionTag.FindStringSubmatch(p.Title); m != nil {
+		w, _ := strconv.Atoi(m[1])
+		h, _ := strconv.Atoi(m[2])
+		return w, h
+	}
+
+	if len(p.Preview.Images) > 0 {
+		src := p.Preview.Images[0].Source
+		return src.Width, src.Height
+	}
+
+	return 0, 0
+}
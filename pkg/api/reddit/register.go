@@ -0,0 +1,28 @@
+package reddit
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "image posts from wallpaper subreddits",
+		Phrase:       searcher.PhraseAccepted,
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringSliceVar(&opts.Subreddits, "reddit-subs", []string{"wallpaper", "wallpapers", "EarthPorn"}, "subreddits to search")
+			fs.StringVar(&opts.Sort, "reddit-sort", "top", "reddit sort: top, hot or new")
+			fs.StringVar(&opts.Time, "reddit-time", "week", "reddit time window: hour, day, week, month, year or all")
+			fs.BoolVar(&opts.NSFW, "reddit-nsfw", false, "allow reddit posts marked nsfw")
+
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				opts.Paging = env.Paging
+				return NewReddit(log, opts)
+			}
+		},
+	})
+}
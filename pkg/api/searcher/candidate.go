@@ -0,0 +1,182 @@
+package searcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// maxFetches bounds the downloads SearchCandidates attempts
+const maxFetches = 5
+
+var (
+	ErrNoCandidates = errors.New("no suitable candidates")
+)
+
+// Candidate is an image a searcher found but has not downloaded yet
+type Candidate struct {
+	// ID identifies the image within its source, the url is used when empty
+	ID  string
+	URL string
+	// Width and Height are zero when the source does not know them before downloading
+	Width, Height int
+	Metadata      Metadata
+	Fetch         func(ctx context.Context) (Image, error)
+	// Fallback is set instead of Fetch on a candidate standing for other sources, it lists their candidates
+	// once every other candidate failed, so they are selected and budgeted like the first ones
+	Fallback func(ctx context.Context) ([]Candidate, error)
+	// Accepted is called once the downloaded image is actually used, nil when the source does not care
+	Accepted func()
+}
+
+// Key identifies the candidate across searches, empty for candidates that cannot be told apart
+func (c Candidate) Key() string {
+	id := c.ID
+	if id == "" {
+		id = c.URL
+	}
+	if id == "" {
+		return ""
+	}
+	return c.Metadata.Source + ":" + id
+}
+
+// Accept tells the source its candidate was used
+func (c Candidate) Accept() {
+	if c.Accepted != nil {
+		c.Accepted()
+	}
+}
+
+// Known reports whether the dimensions are known before downloading
+func (c Candidate) Known() bool { return c.Width > 0 && c.Height > 0 }
+
+// Open downloads the candidate, attaching its metadata unless the image carries its own
+func (c Candidate) Open(ctx context.Context) (Image, error) {
+	img, err := c.Fetch(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if img == nil {
+		return nil, fmt.Errorf("%s: empty image", c.Key())
+	}
+	if _, ok := MetadataOf(img); !ok && c.Metadata.Source != "" {
+		meta := c.Metadata
+		if !c.Known() {
+			meta.Width, meta.Height = img.Size()
+		}
+		return WithMetadata(img, meta), nil
+	}
+	return img, nil
+}
+
+// CandidateSearcher lists images without downloading them
+type CandidateSearcher interface {
+	Candidates(ctx context.Context, q string, res Resolution) ([]Candidate, error)
+}
+
+// Lazy turns a plain search into a candidate of unknown size
+func Lazy(s Searcher, q string, res Resolution) Candidate {
+	return Candidate{
+		Fetch: func(ctx context.Context) (Image, error) {
+			return s.Search(ctx, q, res)
+		},
+	}
+}
+
+// Rank drops candidates known to be smaller than res and orders the rest:
+// fitting aspect ratio first, unknown size next, other ratios last, fallback candidates after all of them.
+// The order within each group is kept, so sources decide between equals
+func Rank(candidates []Candidate, res Resolution) []Candidate {
+	tier := func(c Candidate) int {
+		switch {
+		case !c.Known():
+			return 1
+		case FitsAspectRatio(c.Width, c.Height, res, RatioTolerance):
+			return 0
+		default:
+			return 2
+		}
+	}
+
+	ranked := make([]Candidate, 0, len(candidates))
+	var fallbacks []Candidate
+	for _, c := range candidates {
+		if c.Fallback != nil {
+			fallbacks = append(fallbacks, c)
+			continue
+		}
+		if c.Known() && (c.Width < res.Width || c.Height < res.Height) {
+			continue
+		}
+		ranked = append(ranked, c)
+	}
+
+	sort.SliceStable(ranked, func(i, j int) bool { return tier(ranked[i]) < tier(ranked[j]) })
+	return append(ranked, fallbacks...)
+}
+
+// TooSmall reports why img cannot be used at res, nil when it fits
+func TooSmall(img Image, res Resolution) error {
+	w, h := img.Size()
+	if w < res.Width || h < res.Height {
+		return fmt.Errorf("image too small: %dx%d < %dx%d", w, h, res.Width, res.Height)
+	}
+	return nil
+}
+
+// SearchCandidates implements Search for a CandidateSearcher by downloading the best ranked candidates in turn,
+// at most maxFetches of each source
+func SearchCandidates(ctx context.Context, s CandidateSearcher, q string, res Resolution) (Image, error) {
+	candidates, err := s.Candidates(ctx, q, res)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := Rank(candidates, res)
+	if len(ranked) == 0 {
+		return nil, fmt.Errorf("%w among %d for %s", ErrNoCandidates, len(candidates), q)
+	}
+
+	var errs []error
+	fetches, listed := 0, len(ranked)
+	for len(ranked) > 0 {
+		c := ranked[0]
+		ranked = ranked[1:]
+
+		if c.Fallback != nil {
+			more, err := c.Fallback(ctx)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			// the next source gets a budget of its own
+			fetches, listed = 0, listed+len(more)
+			ranked = append(Rank(more, res), ranked...)
+			continue
+		}
+
+		if fetches >= maxFetches {
+			continue
+		}
+		fetches++
+
+		img, err := c.Open(ctx)
+		if err == nil {
+			if err = TooSmall(img, res); err != nil {
+				_ = img.Close()
+			}
+		}
+		if err == nil {
+			c.Accept()
+			return img, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		errs = append(errs, err)
+	}
+
+	return nil, fmt.Errorf("failed to download any of %d candidates: %w", listed, errors.Join(errs...))
+}
@@ -0,0 +1,118 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"image"
+	"image/png"
+	"io"
+	"slices"
+	"testing"
+)
+
+func ids(candidates []Candidate) []string {
+	out := make([]string, len(candidates))
+	for i, c := range candidates {
+		out[i] = c.ID
+	}
+	return out
+}
+
+func TestRank(t *testing.T) {
+	res := Resolution{Width: 1920, Height: 1080}
+	candidates := []Candidate{
+		{ID: "fallback", Fallback: func(context.Context) ([]Candidate, error) { return nil, nil }},
+		{ID: "square", Width: 3000, Height: 3000},
+		{ID: "unknown"},
+		{ID: "small", Width: 1280, Height: 720},
+		{ID: "wide", Width: 3840, Height: 2160},
+		{ID: "near", Width: 2560, Height: 1600},
+		{ID: "unknown2"},
+	}
+
+	got := ids(Rank(candidates, res))
+	want := []string{"wide", "near", "unknown", "unknown2", "square", "fallback"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Rank = %v, want %v", got, want)
+	}
+}
+
+func TestCandidateKey(t *testing.T) {
+	tests := []struct {
+		c    Candidate
+		want string
+	}{
+		{Candidate{ID: "a1", URL: "https://x/a.jpg", Metadata: Metadata{Source: "s"}}, "s:a1"},
+		{Candidate{URL: "https://x/a.jpg", Metadata: Metadata{Source: "s"}}, "s:https://x/a.jpg"},
+		{Candidate{Metadata: Metadata{Source: "s"}}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Key(); got != tt.want {
+			t.Errorf("Key() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+type staticCandidates []Candidate
+
+func (s staticCandidates) Candidates(context.Context, string, Resolution) ([]Candidate, error) {
+	return s, nil
+}
+
+func pngOf(w, h int) (Image, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, w, h))); err != nil {
+		return nil, err
+	}
+	return DetectSize(io.NopCloser(&buf))
+}
+
+func TestSearchCandidatesTriesFallbackAfterLimit(t *testing.T) {
+	errFetch := errors.New("fetch failed")
+	fetches := 0
+
+	var list staticCandidates
+	for range maxFetches + 3 {
+		list = append(list, Candidate{Fetch: func(context.Context) (Image, error) {
+			fetches++
+			return nil, errFetch
+		}})
+	}
+	list = append(list, Candidate{Fallback: func(context.Context) ([]Candidate, error) {
+		return []Candidate{{Fetch: func(context.Context) (Image, error) {
+			return pngOf(40, 30)
+		}}}, nil
+	}})
+
+	img, err := SearchCandidates(context.Background(), list, "", Resolution{Width: 40, Height: 30})
+	if err != nil {
+		t.Fatalf("SearchCandidates: %v", err)
+	}
+	defer img.Close()
+
+	if fetches != maxFetches {
+		t.Errorf("fetched %d candidates, want %d", fetches, maxFetches)
+	}
+}
+
+func TestOpenAttachesMetadata(t *testing.T) {
+	c := Candidate{
+		ID:       "x",
+		Metadata: Metadata{Source: "s", Title: "t"},
+		Fetch: func(context.Context) (Image, error) {
+			return pngOf(8, 6)
+		},
+	}
+
+	img, err := c.Open(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer img.Close()
+
+	meta, ok := MetadataOf(img)
+	if !ok || meta.Title != "t" || meta.Width != 8 || meta.Height != 6 {
+		t.Errorf("MetadataOf = %+v, %v", meta, ok)
+	}
+}
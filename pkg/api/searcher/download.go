@@ -0,0 +1,50 @@
+package searcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// Download fetches an image over http and detects its size
+func Download(ctx context.Context, client *http.Client, url string) (Image, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("create img req: %w", err)
+	}
+	return DownloadRequest(client, req)
+}
+
+// DownloadRequest is Download for sources that need to set request headers
+func DownloadRequest(client *http.Client, req *http.Request) (Image, error) {
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("download img: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("img status: %d", resp.StatusCode)
+	}
+
+	return DetectSize(resp.Body)
+}
+
+// userAgent sets the User-Agent of requests that do not set their own
+type userAgent struct {
+	next http.RoundTripper
+	ua   string
+}
+
+func (u userAgent) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Header.Get("User-Agent") == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", u.ua)
+	}
+	return u.next.RoundTrip(req)
+}
+
+// UserAgentClient returns a client identifying itself as ua, for apis rejecting the default go user agent
+func UserAgentClient(ua string) http.Client {
+	return http.Client{Transport: userAgent{next: http.DefaultTransport, ua: ua}}
+}
@@ -0,0 +1,44 @@
+package searcher
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDownload(t *testing.T) {
+	var agent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.UserAgent()
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		var buf bytes.Buffer
+		_ = png.Encode(&buf, image.NewGray(image.Rect(0, 0, 8, 6)))
+		_, _ = w.Write(buf.Bytes())
+	}))
+	defer srv.Close()
+
+	client := UserAgentClient("chiasma-test")
+	img, err := Download(context.Background(), &client, srv.URL+"/a.png")
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	defer img.Close()
+
+	if w, h := img.Size(); w != 8 || h != 6 {
+		t.Errorf("Size = %dx%d, want 8x6", w, h)
+	}
+	if agent != "chiasma-test" {
+		t.Errorf("User-Agent = %q, want chiasma-test", agent)
+	}
+
+	if _, err := Download(context.Background(), &client, srv.URL+"/missing"); err == nil {
+		t.Error("Download accepted a 404")
+	}
+}
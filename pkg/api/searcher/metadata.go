@@ -0,0 +1,90 @@
+package searcher
+
+import (
+	"strings"
+
+	"github.com/rs/zerolog"
+)
+
+// Metadata describes where an image comes from and who made it, empty fields are unknown
+type Metadata struct {
+	// Source is the searcher that returned the image
+	Source      string   `json:"source,omitempty"`
+	Title       string   `json:"title,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Author      string   `json:"author,omitempty"`
+	AuthorURL   string   `json:"author_url,omitempty"`
+	PageURL     string   `json:"page_url,omitempty"`
+	License     string   `json:"license,omitempty"`
+	LicenseURL  string   `json:"license_url,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	// Width and Height are the original dimensions, which may differ from the downloaded image
+	Width  int `json:"width,omitempty"`
+	Height int `json:"height,omitempty"`
+}
+
+// Described is implemented by images that carry metadata
+type Described interface {
+	Metadata() Metadata
+}
+
+type describedImage struct {
+	Image
+	meta Metadata
+}
+
+func (d describedImage) Metadata() Metadata { return d.meta }
+
+// WithMetadata attaches meta to img
+func WithMetadata(img Image, meta Metadata) Image {
+	return describedImage{Image: img, meta: meta}
+}
+
+// MetadataOf returns the metadata of img, if any
+func MetadataOf(img Image) (Metadata, bool) {
+	d, ok := img.(Described)
+	if !ok {
+		return Metadata{}, false
+	}
+	return d.Metadata(), true
+}
+
+// String is a one-line human-readable attribution
+func (m Metadata) String() string {
+	var b strings.Builder
+	b.WriteString(m.Title)
+	if m.Author != "" {
+		if b.Len() > 0 {
+			b.WriteString(" by ")
+		}
+		b.WriteString(m.Author)
+	}
+	if m.License != "" {
+		if b.Len() > 0 {
+			b.WriteString(", ")
+		}
+		b.WriteString(m.License)
+	}
+	return b.String()
+}
+
+// MarshalZerologObject logs the non-empty fields
+func (m Metadata) MarshalZerologObject(e *zerolog.Event) {
+	str := func(key, val string) {
+		if val != "" {
+			e.Str(key, val)
+		}
+	}
+	str("source", m.Source)
+	str("title", m.Title)
+	str("author", m.Author)
+	str("author_url", m.AuthorURL)
+	str("page_url", m.PageURL)
+	str("license", m.License)
+	if len(m.Tags) > 0 {
+		e.Strs("tags", m.Tags)
+	}
+	if m.Width > 0 && m.Height > 0 {
+		e.Int("width", m.Width).Int("height", m.Height)
+	}
+}
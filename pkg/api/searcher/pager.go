@@ -0,0 +1,225 @@
+package searcher
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	PageRandom     = "random"
+	PageSequential = "sequential"
+)
+
+// maxPagedKeys bounds how many phrases a pager remembers
+const maxPagedKeys = 200
+
+var (
+	ErrInvalidPageMode = errors.New("page mode must be random or sequential")
+)
+
+// PageOptions configures how deep searchers page through results
+type PageOptions struct {
+	// Depth is the deepest page requested, 1 disables paging
+	Depth int
+	// Mode is PageRandom or PageSequential
+	Mode string
+}
+
+func (o PageOptions) Validate() error {
+	if o.Mode != PageRandom && o.Mode != PageSequential {
+		return fmt.Errorf("%w: %q", ErrInvalidPageMode, o.Mode)
+	}
+	return nil
+}
+
+type pageState struct {
+	// Next is the page a sequential walk requests next
+	Next int `json:"next,omitempty"`
+	// Last is the last page with results, 0 while unknown
+	Last int `json:"last,omitempty"`
+	// Cursors are the tokens of pages reachable only through the previous one
+	Cursors map[int]string `json:"cursors,omitempty"`
+	Updated time.Time      `json:"updated"`
+}
+
+// Pager picks the page to request per phrase and remembers where results ran out,
+// the state is kept in the user cache dir so one-shot runs walk pages too
+type Pager struct {
+	opts PageOptions
+	path string
+
+	mu    sync.Mutex
+	pages map[string]*pageState
+}
+
+// NewPager loads the paging state of the named source, failing to read it only starts over
+func NewPager(name string, opts PageOptions) *Pager {
+	if opts.Depth < 1 {
+		opts.Depth = 1
+	}
+
+	p := &Pager{opts: opts, pages: make(map[string]*pageState)}
+
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return p
+	}
+	p.path = filepath.Join(dir, "chiasma", "pages", name+".json")
+
+	if data, err := os.ReadFile(p.path); err == nil {
+		_ = json.Unmarshal(data, &p.pages)
+	}
+	return p
+}
+
+// PageKey builds a pager key from the phrase and anything else that changes the results
+func PageKey(q string, extra ...string) string {
+	key := q
+	for _, e := range extra {
+		key += "|" + e
+	}
+	return key
+}
+
+// Page returns the 1-based page to request for key
+func (p *Pager) Page(key string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.save()
+
+	st := p.state(key)
+	limit := p.limit(st)
+
+	if p.opts.Mode == PageSequential {
+		page := st.Next
+		if page < 1 || page > limit {
+			page = 1
+		}
+		st.Next = page + 1
+		return page
+	}
+
+	return 1 + rand.IntN(limit)
+}
+
+// Cursor returns a page reachable through a known cursor and that cursor, page 1 has an empty one
+func (p *Pager) Cursor(key string) (int, string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	defer p.save()
+
+	st := p.state(key)
+	limit := p.limit(st)
+
+	reachable := []int{1}
+	for page := range st.Cursors {
+		if page > 1 && page <= limit {
+			reachable = append(reachable, page)
+		}
+	}
+	sort.Ints(reachable)
+
+	page := reachable[rand.IntN(len(reachable))]
+	if p.opts.Mode == PageSequential {
+		page = 1
+		for _, r := range reachable {
+			if r == st.Next {
+				page = r
+			}
+		}
+		st.Next = page + 1
+	}
+
+	return page, st.Cursors[page]
+}
+
+// SetCursor records the token leading to page, an empty one means the previous page was the last
+func (p *Pager) SetCursor(key string, page int, cursor string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.state(key)
+	if cursor == "" {
+		st.Last = page - 1
+		delete(st.Cursors, page)
+	} else {
+		if st.Cursors == nil {
+			st.Cursors = make(map[int]string)
+		}
+		st.Cursors[page] = cursor
+	}
+	p.save()
+}
+
+// SetLast records the last page with results, e.g. from a total count or after an empty page
+func (p *Pager) SetLast(key string, last int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	st := p.state(key)
+	st.Last = max(last, 1)
+	p.save()
+}
+
+// Empty marks page as having no results, a later Page call falls back to the ones before it
+func (p *Pager) Empty(key string, page int) {
+	if page > 1 {
+		p.SetLast(key, page-1)
+	}
+}
+
+func (p *Pager) state(key string) *pageState {
+	st, ok := p.pages[key]
+	if !ok {
+		st = &pageState{}
+		p.pages[key] = st
+	}
+	st.Updated = time.Now()
+	return st
+}
+
+func (p *Pager) limit(st *pageState) int {
+	if st.Last > 0 && st.Last < p.opts.Depth {
+		return st.Last
+	}
+	return p.opts.Depth
+}
+
+// save writes the state atomically, keeping the most recently used keys
+func (p *Pager) save() {
+	if p.path == "" {
+		return
+	}
+
+	if len(p.pages) > maxPagedKeys {
+		keys := make([]string, 0, len(p.pages))
+		for k := range p.pages {
+			keys = append(keys, k)
+		}
+		sort.Slice(keys, func(i, j int) bool { return p.pages[keys[i]].Updated.After(p.pages[keys[j]].Updated) })
+		for _, k := range keys[maxPagedKeys:] {
+			delete(p.pages, k)
+		}
+	}
+
+	data, err := json.Marshal(p.pages)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(p.path), 0755); err != nil {
+		return
+	}
+
+	tmp := p.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return
+	}
+	_ = os.Rename(tmp, p.path)
+}
@@ -0,0 +1,114 @@
+package searcher
+
+import (
+	"fmt"
+	"testing"
+)
+
+func newTestPager(t *testing.T, opts PageOptions) *Pager {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	return NewPager("test", opts)
+}
+
+func TestPagerSequential(t *testing.T) {
+	p := newTestPager(t, PageOptions{Depth: 3, Mode: PageSequential})
+
+	var got []int
+	for i := 0; i < 5; i++ {
+		got = append(got, p.Page("forest"))
+	}
+	if fmt.Sprint(got) != "[1 2 3 1 2]" {
+		t.Errorf("pages = %v, want [1 2 3 1 2]", got)
+	}
+	if page := p.Page("lake"); page != 1 {
+		t.Errorf("first page of another key = %d, want 1", page)
+	}
+}
+
+func TestPagerRandomWithinLast(t *testing.T) {
+	p := newTestPager(t, PageOptions{Depth: 10, Mode: PageRandom})
+	p.SetLast("forest", 3)
+
+	for i := 0; i < 100; i++ {
+		if page := p.Page("forest"); page < 1 || page > 3 {
+			t.Fatalf("page %d past the last page 3", page)
+		}
+	}
+}
+
+func TestPagerEmpty(t *testing.T) {
+	p := newTestPager(t, PageOptions{Depth: 5, Mode: PageSequential})
+
+	p.Page("forest")
+	p.Page("forest")
+	p.Page("forest")
+	p.Empty("forest", 3)
+
+	if page := p.Page("forest"); page != 1 {
+		t.Errorf("page after the empty one = %d, want 1", page)
+	}
+	if page := p.Page("forest"); page != 2 {
+		t.Errorf("page = %d, want 2", page)
+	}
+	if page := p.Page("forest"); page != 1 {
+		t.Errorf("page past the last one = %d, want 1", page)
+	}
+}
+
+func TestPagerDepthOne(t *testing.T) {
+	p := newTestPager(t, PageOptions{Mode: PageRandom})
+	for i := 0; i < 10; i++ {
+		if page := p.Page("forest"); page != 1 {
+			t.Fatalf("page = %d with paging disabled", page)
+		}
+	}
+}
+
+func TestPagerCursor(t *testing.T) {
+	p := newTestPager(t, PageOptions{Depth: 3, Mode: PageSequential})
+
+	if page, cursor := p.Cursor("forest"); page != 1 || cursor != "" {
+		t.Fatalf("Cursor = %d %q, want 1 and no cursor", page, cursor)
+	}
+	p.SetCursor("forest", 2, "t2")
+
+	if page, cursor := p.Cursor("forest"); page != 2 || cursor != "t2" {
+		t.Fatalf("Cursor = %d %q, want 2 t2", page, cursor)
+	}
+	// page 3 is unknown, the walk starts over
+	if page, _ := p.Cursor("forest"); page != 1 {
+		t.Errorf("Cursor = %d without a cursor for page 3, want 1", page)
+	}
+
+	p.SetCursor("forest", 2, "")
+	for i := 0; i < 10; i++ {
+		if page, _ := p.Cursor("forest"); page != 1 {
+			t.Fatalf("Cursor = %d after the listing ended on page 1", page)
+		}
+	}
+}
+
+func TestPagerPersists(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	opts := PageOptions{Depth: 5, Mode: PageSequential}
+
+	p := NewPager("test", opts)
+	p.Page("forest")
+	p.Page("forest")
+
+	if page := NewPager("test", opts).Page("forest"); page != 3 {
+		t.Errorf("page after reload = %d, want 3", page)
+	}
+}
+
+func TestPageOptionsValidate(t *testing.T) {
+	for _, mode := range []string{PageRandom, PageSequential} {
+		if err := (PageOptions{Mode: mode}).Validate(); err != nil {
+			t.Errorf("Validate(%s) = %v", mode, err)
+		}
+	}
+	if err := (PageOptions{Mode: "deep"}).Validate(); err == nil {
+		t.Error("Validate accepted an unknown mode")
+	}
+}
@@ -0,0 +1,112 @@
+package searcher
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+// Env carries settings shared by every source
+type Env struct {
+	SaveDir string
+	Paging  PageOptions
+}
+
+// Factory builds a searcher from options bound to a parsed flag set
+type Factory func(log zerolog.Logger, env Env) (Searcher, error)
+
+// Registration describes a source, packages register themselves from init
+type Registration struct {
+	Name        string
+	Description string
+	// Phrase is the phrase need with the default options, options may change it
+	// and the built searcher reports its own through PhraseNeedOf
+	Phrase       PhraseNeed
+	NeedsNetwork bool
+	// Bind registers the source flags on fs and returns a factory reading their parsed values
+	Bind func(fs *flag.FlagSet) Factory
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Registration)
+)
+
+// Register makes a source available by name, registering a name twice panics
+func Register(r Registration) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if r.Name == "" || r.Bind == nil {
+		panic("searcher: incomplete registration")
+	}
+	if _, dup := registry[r.Name]; dup {
+		panic("searcher: Register called twice for " + r.Name)
+	}
+	registry[r.Name] = r
+}
+
+// Lookup returns the registration of the named source
+func Lookup(name string) (Registration, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	r, ok := registry[name]
+	return r, ok
+}
+
+// Registered returns every registration sorted by name
+func Registered() []Registration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	regs := make([]Registration, 0, len(registry))
+	for _, r := range registry {
+		regs = append(regs, r)
+	}
+	sort.Slice(regs, func(i, j int) bool { return regs[i].Name < regs[j].Name })
+	return regs
+}
+
+// Names returns the registered source names sorted
+func Names() []string {
+	regs := Registered()
+	names := make([]string, len(regs))
+	for i, r := range regs {
+		names[i] = r.Name
+	}
+	return names
+}
+
+// UnknownError wraps ErrUnknownSearcher with the available names
+func UnknownError(name string) error {
+	return fmt.Errorf("%w %q, available: %s", ErrUnknownSearcher, name, strings.Join(Names(), ", "))
+}
+
+// Sources builds registered searchers from a parsed flag set
+type Sources struct {
+	factories map[string]Factory
+}
+
+// Bind registers the flags of every registered source on fs
+func Bind(fs *flag.FlagSet) *Sources {
+	regs := Registered()
+	s := &Sources{factories: make(map[string]Factory, len(regs))}
+	for _, r := range regs {
+		s.factories[r.Name] = r.Bind(fs)
+	}
+	return s
+}
+
+// New builds the named searcher, fs must be parsed first
+func (s *Sources) New(log zerolog.Logger, name string, env Env) (Searcher, error) {
+	factory, ok := s.factories[name]
+	if !ok {
+		return nil, UnknownError(name)
+	}
+	return factory(log, env)
+}
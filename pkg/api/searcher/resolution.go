@@ -3,6 +3,7 @@ package searcher
 import (
 	"errors"
 	"fmt"
+	"math"
 	"regexp"
 )
 
@@ -34,3 +35,23 @@ func (r *Resolution) Set(s string) error {
 func (r *Resolution) Type() string {
 	return "resolution"
 }
+
+// RatioTolerance is how far an image aspect ratio may deviate from the target and still fit,
+// lenient since wallpaper tools crop anyway
+const RatioTolerance = 0.35
+
+// FitsAspectRatio reports whether a w x h image is within tolerance of the target aspect ratio,
+// a zero target accepts everything
+func FitsAspectRatio(w, h int, target Resolution, tolerance float64) bool {
+	if target.Width == 0 || target.Height == 0 {
+		return true
+	}
+	if w == 0 || h == 0 {
+		return false
+	}
+
+	targetRatio := float64(target.Width) / float64(target.Height)
+	imgRatio := float64(w) / float64(h)
+
+	return math.Abs(targetRatio-imgRatio) <= tolerance
+}
@@ -23,6 +23,63 @@ type Searcher interface {
 	Search(ctx context.Context, q string, resolution Resolution) (Image, error)
 }
 
+// Phraseless is implemented by searchers that do not use the search phrase
+type Phraseless interface {
+	IgnoresPhrase() bool
+}
+
+// IgnoresPhrase reports whether s can run without a search phrase
+func IgnoresPhrase(s Searcher) bool {
+	p, ok := s.(Phraseless)
+	return ok && p.IgnoresPhrase()
+}
+
+// PhraseOptional is implemented by searchers that use a phrase when there is one but do not require it
+type PhraseOptional interface {
+	AcceptsEmptyPhrase() bool
+}
+
+// RequiresPhrase reports whether s cannot run without a search phrase
+func RequiresPhrase(s Searcher) bool {
+	if IgnoresPhrase(s) {
+		return false
+	}
+	p, ok := s.(PhraseOptional)
+	return !ok || !p.AcceptsEmptyPhrase()
+}
+
+// PhraseNeed tells whether a source needs a search phrase
+type PhraseNeed int
+
+const (
+	PhraseRequired PhraseNeed = iota
+	PhraseAccepted
+	PhraseIgnored
+)
+
+func (n PhraseNeed) String() string {
+	switch n {
+	case PhraseAccepted:
+		return "optional phrase"
+	case PhraseIgnored:
+		return "no phrase"
+	default:
+		return "phrase"
+	}
+}
+
+// PhraseNeedOf reports how s uses the search phrase with the options it was built with
+func PhraseNeedOf(s Searcher) PhraseNeed {
+	switch {
+	case IgnoresPhrase(s):
+		return PhraseIgnored
+	case RequiresPhrase(s):
+		return PhraseRequired
+	default:
+		return PhraseAccepted
+	}
+}
+
 type detectedImage struct {
 	io.Reader
 	closer io.Closer
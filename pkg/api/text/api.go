@@ -0,0 +1,213 @@
+package text
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "text"
+
+const (
+	SourcePhrase  = "phrase"
+	SourceFortune = "fortune"
+	SourceQuotes  = "quotes"
+	SourceFile    = "file"
+
+	defaultWidth  = 1920
+	defaultHeight = 1080
+)
+
+var (
+	ErrUnknownSource = errors.New("text: unknown source")
+	ErrNoFile        = errors.New("text: --text-file is required for quotes and file sources")
+	ErrEmptyText     = errors.New("text: nothing to render")
+	ErrUnknownAlign  = errors.New("text: unknown alignment")
+
+	// quoteSeparator splits fortune-style files on lone % lines or blank lines
+	quoteSeparator = regexp.MustCompile(`\n\s*%\s*\n|\n\s*\n`)
+)
+
+// Options configures the text renderer
+type Options struct {
+	// Source is phrase, fortune, quotes or file
+	Source string
+	// File is the quotes or plain text file
+	File string
+	// Font is a ttf/otf file, the embedded Go font is used when empty
+	Font string
+	// Size is the font size in pixels, derived from the resolution when zero
+	Size float64
+	// Align is left, center or right
+	Align string
+	// Color is the text color as #rgb, #rrggbb or #rrggbbaa
+	Color string
+	// Background is a color or a path to an image
+	Background string
+}
+
+type Text struct {
+	log  zerolog.Logger
+	opts Options
+	font []byte
+}
+
+func NewText(log zerolog.Logger, opts Options) (*Text, error) {
+	switch opts.Source {
+	case "":
+		opts.Source = SourcePhrase
+	case SourcePhrase, SourceFortune:
+	case SourceQuotes, SourceFile:
+		if opts.File == "" {
+			return nil, ErrNoFile
+		}
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownSource, opts.Source)
+	}
+
+	switch opts.Align {
+	case "":
+		opts.Align = AlignCenter
+	case AlignLeft, AlignCenter, AlignRight:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownAlign, opts.Align)
+	}
+
+	if _, err := parseColor(opts.Color); opts.Color != "" && err != nil {
+		return nil, err
+	}
+
+	font, err := loadFont(opts.Font)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Text{
+		log:  log.With().Str("component", "text").Logger(),
+		opts: opts,
+		font: font,
+	}, nil
+}
+
+// IgnoresPhrase is true unless the phrase itself is rendered
+func (t *Text) IgnoresPhrase() bool { return t.opts.Source != SourcePhrase }
+
+func (t *Text) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	log := t.log.With().Str("op", "Search").Logger()
+
+	w, h := res.Width, res.Height
+	if w <= 0 || h <= 0 {
+		w, h = defaultWidth, defaultHeight
+	}
+
+	content, err := t.content(ctx, q)
+	if err != nil {
+		return nil, err
+	}
+	log.Debug().Str("source", t.opts.Source).Int("len", len(content)).Msg("rendering text")
+
+	canvas, err := t.background(w, h)
+	if err != nil {
+		return nil, err
+	}
+
+	fg := color.NRGBA{R: 0xee, G: 0xee, B: 0xee, A: 0xff}
+	if t.opts.Color != "" {
+		fg, _ = parseColor(t.opts.Color)
+	}
+
+	if err := drawText(canvas, content, t.font, t.opts.Size, t.opts.Align, fg); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := enc.Encode(&buf, canvas); err != nil {
+		return nil, fmt.Errorf("encode png: %w", err)
+	}
+
+	img, err := searcher.DetectSize(&buf)
+	if err != nil {
+		return nil, err
+	}
+
+	return searcher.WithMetadata(img, searcher.Metadata{Source: Name, Description: content}), nil
+}
+
+// content returns the text to render according to the configured source
+func (t *Text) content(ctx context.Context, q string) (string, error) {
+	var text string
+
+	switch t.opts.Source {
+	case SourceFortune:
+		out, err := exec.CommandContext(ctx, "fortune").Output()
+		if err != nil {
+			return "", fmt.Errorf("run fortune: %w", err)
+		}
+		text = string(out)
+	case SourceQuotes:
+		data, err := os.ReadFile(t.opts.File)
+		if err != nil {
+			return "", fmt.Errorf("read quotes: %w", err)
+		}
+		quotes := splitQuotes(string(data))
+		if len(quotes) == 0 {
+			return "", ErrEmptyText
+		}
+		text = quotes[rand.IntN(len(quotes))]
+	case SourceFile:
+		data, err := os.ReadFile(t.opts.File)
+		if err != nil {
+			return "", fmt.Errorf("read file: %w", err)
+		}
+		text = string(data)
+	default:
+		text = q
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\t", "    "))
+	if text == "" {
+		return "", ErrEmptyText
+	}
+	return text, nil
+}
+
+// background fills the canvas with a color or covers it with a scaled image
+func (t *Text) background(w, h int) (*image.RGBA, error) {
+	bg := t.opts.Background
+	if bg == "" {
+		bg = "#1e1e2e"
+	}
+
+	if strings.HasPrefix(bg, "#") {
+		c, err := parseColor(bg)
+		if err != nil {
+			return nil, err
+		}
+		return fill(w, h, c), nil
+	}
+
+	return cover(bg, w, h)
+}
+
+func splitQuotes(data string) []string {
+	var quotes []string
+	for _, q := range quoteSeparator.Split(strings.ReplaceAll(data, "\r\n", "\n"), -1) {
+		if q = strings.TrimSpace(q); q != "" && q != "%" {
+			quotes = append(quotes, q)
+		}
+	}
+	return quotes
+}
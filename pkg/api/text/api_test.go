@@ -0,0 +1,34 @@
+package text
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestNewTextAlign(t *testing.T) {
+	tests := []struct {
+		align string
+		want  string
+		err   error
+	}{
+		{"", AlignCenter, nil},
+		{AlignLeft, AlignLeft, nil},
+		{AlignRight, AlignRight, nil},
+		{"justify", "", ErrUnknownAlign},
+		{"Left", "", ErrUnknownAlign},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.align, func(t *testing.T) {
+			txt, err := NewText(zerolog.Nop(), Options{Align: tt.align})
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NewText error = %v, want %v", err, tt.err)
+			}
+			if err == nil && txt.opts.Align != tt.want {
+				t.Errorf("Align = %q, want %q", txt.opts.Align, tt.want)
+			}
+		})
+	}
+}
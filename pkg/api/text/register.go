@@ -0,0 +1,28 @@
+package text
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:        Name,
+		Description: "quotes, fortune, a text file or the phrase rendered onto a background",
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.Source, "text-source", SourcePhrase, "text to render: phrase, fortune, quotes or file")
+			fs.StringVar(&opts.File, "text-file", "", "quotes file (separated by % or blank lines) or plain text file to render")
+			fs.StringVar(&opts.Font, "text-font", "", "ttf/otf font file (default embedded Go font)")
+			fs.Float64Var(&opts.Size, "text-size", 0, "font size in pixels (default from resolution)")
+			fs.StringVar(&opts.Align, "text-align", AlignCenter, "text alignment: left, center or right")
+			fs.StringVar(&opts.Color, "text-color", "#eeeeee", "text color")
+			fs.StringVar(&opts.Background, "text-background", "#1e1e2e", "background color or image path")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewText(log, opts)
+			}
+		},
+	})
+}
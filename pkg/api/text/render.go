@@ -0,0 +1,189 @@
+package text
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"os"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+const (
+	AlignLeft   = "left"
+	AlignCenter = "center"
+	AlignRight  = "right"
+
+	// textArea is the share of the canvas the text block may occupy
+	textArea    = 0.8
+	lineSpacing = 1.3
+	minFontSize = 10
+	shrinkStep  = 0.9
+)
+
+var (
+	ErrInvalidColor = errors.New("text: invalid color")
+)
+
+func loadFont(path string) ([]byte, error) {
+	if path == "" {
+		return goregular.TTF, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read font: %w", err)
+	}
+
+	if _, err := opentype.Parse(data); err != nil {
+		return nil, fmt.Errorf("parse font %s: %w", path, err)
+	}
+	return data, nil
+}
+
+// drawText wraps content to the canvas width and centers the block vertically,
+// shrinking the font until everything fits
+func drawText(dst *image.RGBA, content string, fontData []byte, size float64, align string, fg color.Color) error {
+	parsed, err := opentype.Parse(fontData)
+	if err != nil {
+		return fmt.Errorf("parse font: %w", err)
+	}
+
+	bounds := dst.Bounds()
+	maxW := int(float64(bounds.Dx()) * textArea)
+	maxH := int(float64(bounds.Dy()) * textArea)
+
+	if size <= 0 {
+		size = float64(bounds.Dy()) / 18
+	}
+
+	for {
+		face, err := opentype.NewFace(parsed, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+		if err != nil {
+			return fmt.Errorf("create font face: %w", err)
+		}
+
+		lines := wrap(face, content, maxW)
+		lineH := int(float64(face.Metrics().Height.Ceil()) * lineSpacing)
+
+		if len(lines)*lineH <= maxH || size*shrinkStep < minFontSize {
+			drawLines(dst, face, lines, lineH, align, fg)
+			return face.Close()
+		}
+
+		_ = face.Close()
+		size *= shrinkStep
+	}
+}
+
+func drawLines(dst *image.RGBA, face font.Face, lines []string, lineH int, align string, fg color.Color) {
+	bounds := dst.Bounds()
+	margin := int(float64(bounds.Dx()) * (1 - textArea) / 2)
+
+	d := font.Drawer{Dst: dst, Src: image.NewUniform(fg), Face: face}
+	ascent := face.Metrics().Ascent.Ceil()
+	top := (bounds.Dy()-len(lines)*lineH)/2 + ascent
+
+	for i, line := range lines {
+		width := d.MeasureString(line).Ceil()
+
+		x := margin
+		switch align {
+		case AlignRight:
+			x = bounds.Dx() - margin - width
+		case AlignLeft:
+		default:
+			x = (bounds.Dx() - width) / 2
+		}
+
+		d.Dot = fixed.P(x, top+i*lineH)
+		d.DrawString(line)
+	}
+}
+
+// wrap keeps explicit line breaks and breaks longer lines on spaces
+func wrap(face font.Face, content string, maxW int) []string {
+	var lines []string
+
+	for _, paragraph := range strings.Split(content, "\n") {
+		words := strings.Fields(paragraph)
+		if len(words) == 0 {
+			lines = append(lines, "")
+			continue
+		}
+
+		line := words[0]
+		for _, word := range words[1:] {
+			candidate := line + " " + word
+			if font.MeasureString(face, candidate).Ceil() > maxW {
+				lines = append(lines, line)
+				line = word
+				continue
+			}
+			line = candidate
+		}
+		lines = append(lines, line)
+	}
+
+	return lines
+}
+
+func fill(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+// cover scales the image at path to fill w x h, cropping the overflow around the center
+func cover(path string, w, h int) (*image.RGBA, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open background: %w", err)
+	}
+	defer f.Close()
+
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode background: %w", err)
+	}
+
+	sb := src.Bounds()
+	scale := max(float64(w)/float64(sb.Dx()), float64(h)/float64(sb.Dy()))
+	sw, sh := int(float64(w)/scale), int(float64(h)/scale)
+	crop := image.Rect(0, 0, sw, sh).Add(sb.Min).Add(image.Pt((sb.Dx()-sw)/2, (sb.Dy()-sh)/2))
+
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, xdraw.Src, nil)
+
+	return dst, nil
+}
+
+func parseColor(s string) (color.NRGBA, error) {
+	hex := strings.TrimPrefix(s, "#")
+	c := color.NRGBA{A: 0xff}
+
+	var err error
+	switch len(hex) {
+	case 3:
+		_, err = fmt.Sscanf(hex, "%1x%1x%1x", &c.R, &c.G, &c.B)
+		c.R, c.G, c.B = c.R*0x11, c.G*0x11, c.B*0x11
+	case 6:
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x", &c.R, &c.G, &c.B)
+	case 8:
+		_, err = fmt.Sscanf(hex, "%02x%02x%02x%02x", &c.R, &c.G, &c.B, &c.A)
+	default:
+		err = ErrInvalidColor
+	}
+
+	if err != nil {
+		return color.NRGBA{}, fmt.Errorf("%w: %s", ErrInvalidColor, s)
+	}
+	return c, nil
+}
@@ -21,34 +21,89 @@ var (
 )
 
 const (
-	searchQuery = "https://unsplash.com/napi/search/photos?page=1&per_page=20&query=%s&xp=reset-search-state%%3Aexperiment"
+	searchQuery = "https://unsplash.com/napi/search/photos?page=%d&per_page=20&query=%s&xp=reset-search-state%%3Aexperiment"
 )
 
+// Options configures the unsplash searcher,
+// without an access key the private web endpoint is scraped instead of the public api
+type Options struct {
+	AccessKey string
+	// Orientation is landscape, portrait or squarish, derived from the resolution when empty
+	Orientation string
+	// Collections is a comma-separated list of collection ids
+	Collections string
+	// ContentFilter is low or high
+	ContentFilter string
+	Paging        searcher.PageOptions
+}
+
 type Unsplash struct {
 	log    zerolog.Logger
 	client api
+	pager  *searcher.Pager
+	opts   Options
 }
 
-func NewUnsplash(log zerolog.Logger) *Unsplash {
+func NewUnsplash(log zerolog.Logger, opts Options) *Unsplash {
 	return &Unsplash{
-		log: log.With().Str("component", "unsplash").Logger(),
+		log:   log.With().Str("component", "unsplash").Logger(),
+		opts:  opts,
+		pager: searcher.NewPager(Name, opts.Paging),
 	}
 }
 
 type SearchResult struct {
-	Results []Photo `json:"results"`
+	Results    []Photo `json:"results"`
+	TotalPages int     `json:"total_pages"`
 }
 
 type Photo struct {
-	Id     string `json:"id"`
-	Width  int    `json:"width"`
-	Height int    `json:"height"`
-	Urls   struct {
+	Id             string `json:"id"`
+	Width          int    `json:"width"`
+	Height         int    `json:"height"`
+	Description    string `json:"description"`
+	AltDescription string `json:"alt_description"`
+	Urls           struct {
 		Full string `json:"full"`
 	} `json:"urls"`
+	Links struct {
+		HTML             string `json:"html"`
+		DownloadLocation string `json:"download_location"`
+	} `json:"links"`
+	User struct {
+		Name  string `json:"name"`
+		Links struct {
+			HTML string `json:"html"`
+		} `json:"links"`
+	} `json:"user"`
+	Tags []struct {
+		Title string `json:"title"`
+	} `json:"tags"`
 	Premium bool `json:"premium"`
 }
 
+func (p Photo) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:      Name,
+		Title:       p.Description,
+		Description: p.AltDescription,
+		Author:      p.User.Name,
+		AuthorURL:   p.User.Links.HTML,
+		PageURL:     p.Links.HTML,
+		License:     "Unsplash License",
+		LicenseURL:  "https://unsplash.com/license",
+		Width:       p.Width,
+		Height:      p.Height,
+	}
+	if meta.Title == "" {
+		meta.Title = p.AltDescription
+	}
+	for _, tag := range p.Tags {
+		meta.Tags = append(meta.Tags, tag.Title)
+	}
+	return meta
+}
+
 type unsplashImage struct {
 	io.ReadCloser
 	w, h int
@@ -58,13 +113,71 @@ func (i unsplashImage) Size() (int, int) {
 	return i.w, i.h
 }
 
+// AcceptsEmptyPhrase is true with an access key, an empty phrase then asks for random photos
+func (u *Unsplash) AcceptsEmptyPhrase() bool { return u.opts.AccessKey != "" }
+
 func (u *Unsplash) Search(ctx context.Context, q string, resolution searcher.Resolution) (searcher.Image, error) {
-	log := u.log.With().Str("op", "Search").Logger()
+	return searcher.SearchCandidates(ctx, u, q, resolution)
+}
+
+func (u *Unsplash) Candidates(ctx context.Context, q string, resolution searcher.Resolution) ([]searcher.Candidate, error) {
+	if u.opts.AccessKey != "" {
+		return u.officialCandidates(ctx, q, resolution)
+	}
+
+	return u.scrape(ctx, q, resolution)
+}
+
+// candidate downloads photo scaled to resolution, before is called right before the download
+func (u *Unsplash) candidate(photo Photo, resolution searcher.Resolution, before func(ctx context.Context)) searcher.Candidate {
+	return searcher.Candidate{
+		ID:       photo.Id,
+		URL:      photo.Urls.Full,
+		Width:    photo.Width,
+		Height:   photo.Height,
+		Metadata: photo.metadata(),
+		Fetch: func(ctx context.Context) (searcher.Image, error) {
+			if before != nil {
+				before(ctx)
+			}
+			return u.download(ctx, photo, resolution)
+		},
+	}
+}
+
+func (u *Unsplash) scrape(ctx context.Context, q string, resolution searcher.Resolution) ([]searcher.Candidate, error) {
+	key := searcher.PageKey(q)
+	page := u.pager.Page(key)
+
+	photos, err := u.scrapePage(ctx, q, key, page)
+	if err == nil && len(photos) == 0 && page > 1 {
+		u.pager.Empty(key, page)
+		photos, err = u.scrapePage(ctx, q, key, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoPhotos, q)
+	}
+
+	rand.Shuffle(len(photos), func(i, j int) { photos[i], photos[j] = photos[j], photos[i] })
+
+	candidates := make([]searcher.Candidate, 0, len(photos))
+	for _, photo := range photos {
+		candidates = append(candidates, u.candidate(photo, resolution, nil))
+	}
+	return candidates, nil
+}
+
+func (u *Unsplash) scrapePage(ctx context.Context, q string, key string, page int) ([]Photo, error) {
+	log := u.log.With().Str("op", "scrape").Logger()
 	req, err := http.NewRequestWithContext(
 		ctx,
 		http.MethodGet,
 		fmt.Sprintf(
 			searchQuery,
+			page,
 			url.QueryEscape(q),
 		),
 		nil,
@@ -82,36 +195,55 @@ func (u *Unsplash) Search(ctx context.Context, q string, resolution searcher.Res
 	req.Header.Set("Sec-Fetch-Site", "same-origin")
 	req.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux aarch64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/143.0.0.0 Safari/537.36 CrKey/1.54.250320")
 
-	log.Trace().Msgf("requesting unsplash search for: %s", q)
-	photo, err := u.tryFetch(req)
+	log.Trace().Int("page", page).Msgf("requesting unsplash search for: %s", q)
+	photos, totalPages, err := u.tryFetch(req)
+	if totalPages > 0 {
+		u.pager.SetLast(key, totalPages)
+	}
+	return photos, err
+}
+
+func (u *Unsplash) download(ctx context.Context, photo Photo, resolution searcher.Resolution) (searcher.Image, error) {
+	imgURL := fmt.Sprintf("%s&w=%d&h=%d", photo.Urls.Full, resolution.Width, resolution.Height)
+	u.log.Trace().Msgf("requesting image from unsplash: %s", imgURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, imgURL, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	imgURL := fmt.Sprintf("%s&w=%d&h=%d", photo.Urls.Full, resolution.Width, resolution.Height)
-	log.Trace().Msgf("requesting image from unsplash: %s", imgURL)
-	get, err := u.client.Get(imgURL)
+	get, err := u.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	return unsplashImage{ReadCloser: get.Body, w: photo.Width, h: photo.Height}, nil
+	if get.StatusCode != http.StatusOK {
+		get.Body.Close()
+		return nil, fmt.Errorf("download status: %d", get.StatusCode)
+	}
+	img := unsplashImage{ReadCloser: get.Body, w: photo.Width, h: photo.Height}
+	return searcher.WithMetadata(img, photo.metadata()), nil
 }
 
-func (u *Unsplash) tryFetch(req *http.Request) (Photo, error) {
+// tryFetch returns the free photos of a search page and the number of pages, an empty page is not an error
+func (u *Unsplash) tryFetch(req *http.Request) ([]Photo, int, error) {
 	log := u.log.With().Str("op", "tryFetch").Logger()
 	for i := 0; i < 5; i++ {
 		resp, err := u.client.Do(req)
 		if err != nil {
-			return Photo{}, fmt.Errorf("server returned an error: %w", err)
+			return nil, 0, fmt.Errorf("server returned an error: %w", err)
 		}
 
 		var r SearchResult
 		if decodeErr := json.NewDecoder(resp.Body).Decode(&r); decodeErr != nil {
 			resp.Body.Close()
-			return Photo{}, fmt.Errorf("error decoding response: %w", decodeErr)
+			return nil, 0, fmt.Errorf("error decoding response: %w", decodeErr)
 		}
 		resp.Body.Close()
 
+		if len(r.Results) == 0 {
+			return nil, r.TotalPages, nil
+		}
+
 		var candidates []Photo
 		for _, photo := range r.Results {
 			if !photo.Premium {
@@ -120,13 +252,13 @@ func (u *Unsplash) tryFetch(req *http.Request) (Photo, error) {
 		}
 
 		if len(candidates) > 0 {
-			return candidates[rand.Intn(len(candidates))], nil
+			return candidates, r.TotalPages, nil
 		}
 
 		log.Trace().Msg("got a watermarked photo, trying again")
 	}
 
-	return Photo{}, errors.New("failed to fetch watermarked photo after multiple attempts")
+	return nil, 0, errors.New("failed to fetch watermarked photo after multiple attempts")
 }
 
 type api struct {
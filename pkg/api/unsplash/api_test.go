@@ -0,0 +1,44 @@
+package unsplash
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+func TestDownloadStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "rate limit exceeded", http.StatusForbidden)
+			return
+		}
+		_, _ = io.WriteString(w, "image")
+	}))
+	defer srv.Close()
+
+	u := NewUnsplash(zerolog.Nop(), Options{})
+	res := searcher.Resolution{Width: 1920, Height: 1080}
+
+	var photo Photo
+	photo.Urls.Full = srv.URL + "/missing?ixid=1"
+	if img, err := u.download(context.Background(), photo, res); err == nil {
+		_ = img.Close()
+		t.Error("download accepted an error response as an image")
+	}
+
+	photo.Urls.Full = srv.URL + "/photo?ixid=1"
+	img, err := u.download(context.Background(), photo, res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer img.Close()
+
+	if body, _ := io.ReadAll(img); string(body) != "image" {
+		t.Errorf("body = %q", body)
+	}
+}
@@ -0,0 +1,163 @@
+package unsplash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+)
+
+const (
+	officialURL = "https://api.unsplash.com"
+
+	officialPerPage = 30
+	randomCount     = 10
+)
+
+var (
+	ErrNoPhotos = errors.New("unsplash: no photos found")
+)
+
+// officialCandidates uses the public api, a query searches photos while an empty one asks for random photos
+func (u *Unsplash) officialCandidates(ctx context.Context, q string, resolution searcher.Resolution) ([]searcher.Candidate, error) {
+	photos, err := u.fetchOfficialPaged(ctx, q, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []searcher.Candidate
+	for _, photo := range photos {
+		if photo.Premium {
+			continue
+		}
+		candidates = append(candidates, u.candidate(photo, resolution, func(ctx context.Context) {
+			// the api guidelines require reporting every download
+			if err := u.trackDownload(ctx, photo); err != nil {
+				u.log.Warn().Err(err).Str("id", photo.Id).Msg("failed to track download")
+			}
+		}))
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoPhotos, q)
+	}
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	return candidates, nil
+}
+
+// fetchOfficialPaged walks search pages, random photos have none
+func (u *Unsplash) fetchOfficialPaged(ctx context.Context, q string, resolution searcher.Resolution) ([]Photo, error) {
+	if q == "" {
+		photos, _, err := u.fetchOfficial(ctx, q, resolution, 0)
+		return photos, err
+	}
+
+	key := searcher.PageKey(q, u.orientation(resolution), u.opts.Collections, u.opts.ContentFilter)
+	page := u.pager.Page(key)
+
+	photos, totalPages, err := u.fetchOfficial(ctx, q, resolution, page)
+	if err == nil && len(photos) == 0 && page > 1 {
+		u.pager.Empty(key, page)
+		photos, totalPages, err = u.fetchOfficial(ctx, q, resolution, 1)
+	}
+	if totalPages > 0 {
+		u.pager.SetLast(key, totalPages)
+	}
+	return photos, err
+}
+
+func (u *Unsplash) fetchOfficial(ctx context.Context, q string, resolution searcher.Resolution, page int) ([]Photo, int, error) {
+	params := url.Values{}
+	params.Set("orientation", u.orientation(resolution))
+	if u.opts.Collections != "" {
+		params.Set("collections", u.opts.Collections)
+	}
+	if u.opts.ContentFilter != "" {
+		params.Set("content_filter", u.opts.ContentFilter)
+	}
+
+	endpoint := officialURL + "/photos/random"
+	if q != "" {
+		endpoint = officialURL + "/search/photos"
+		params.Set("query", q)
+		params.Set("per_page", strconv.Itoa(officialPerPage))
+		params.Set("page", strconv.Itoa(page))
+	} else {
+		params.Set("count", strconv.Itoa(randomCount))
+	}
+
+	resp, err := u.officialGet(ctx, endpoint+"?"+params.Encode())
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	if q == "" {
+		var photos []Photo
+		if err := json.NewDecoder(resp.Body).Decode(&photos); err != nil {
+			return nil, 0, fmt.Errorf("error decoding response: %w", err)
+		}
+		return photos, 0, nil
+	}
+
+	var r SearchResult
+	if err := json.NewDecoder(resp.Body).Decode(&r); err != nil {
+		return nil, 0, fmt.Errorf("error decoding response: %w", err)
+	}
+	return r.Results, r.TotalPages, nil
+}
+
+func (u *Unsplash) trackDownload(ctx context.Context, photo Photo) error {
+	if photo.Links.DownloadLocation == "" {
+		return nil
+	}
+
+	resp, err := u.officialGet(ctx, photo.Links.DownloadLocation)
+	if err != nil {
+		return err
+	}
+	return resp.Body.Close()
+}
+
+func (u *Unsplash) officialGet(ctx context.Context, endpoint string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Client-ID "+u.opts.AccessKey)
+	req.Header.Set("Accept-Version", "v1")
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("server returned an error: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	return resp, nil
+}
+
+func (u *Unsplash) orientation(resolution searcher.Resolution) string {
+	if u.opts.Orientation != "" {
+		return u.opts.Orientation
+	}
+
+	switch {
+	case resolution.Width > resolution.Height:
+		return "landscape"
+	case resolution.Width < resolution.Height:
+		return "portrait"
+	default:
+		return "squarish"
+	}
+}
@@ -0,0 +1,29 @@
+package unsplash
+
+import (
+	"os"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "unsplash photos, official api with a key or scraping without",
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.AccessKey, "unsplash-key", os.Getenv("UNSPLASH_ACCESS_KEY"), "unsplash api access key, scraping is used when empty")
+			fs.StringVar(&opts.Orientation, "unsplash-orientation", "", "unsplash orientation: landscape, portrait or squarish (default from resolution)")
+			fs.StringVar(&opts.Collections, "unsplash-collections", "", "comma-separated unsplash collection ids")
+			fs.StringVar(&opts.ContentFilter, "unsplash-content-filter", "low", "unsplash content filter: low or high")
+
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				opts.Paging = env.Paging
+				return NewUnsplash(log, opts), nil
+			}
+		},
+	})
+}
@@ -0,0 +1,197 @@
+package wallhaven
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "wallhaven"
+
+const (
+	searchURL = "https://wallhaven.cc/api/v1/search"
+)
+
+var (
+	ErrAPIKeyRequired = errors.New("wallhaven: api key is required for sketchy/nsfw purity")
+	ErrInvalidFilter  = errors.New("wallhaven: filter must be a 3-digit mask of 0 and 1")
+	ErrNoResults      = errors.New("wallhaven: no results")
+)
+
+// Options configures the wallhaven searcher
+type Options struct {
+	// APIKey is needed only for sketchy and nsfw purity
+	APIKey string
+	// Purity is a sfw/sketchy/nsfw mask, e.g. "100"
+	Purity string
+	// Categories is a general/anime/people mask, e.g. "111"
+	Categories string
+}
+
+type Wallhaven struct {
+	log    zerolog.Logger
+	client http.Client
+	opts   Options
+}
+
+func NewWallhaven(log zerolog.Logger, opts Options) (*Wallhaven, error) {
+	if opts.Purity == "" {
+		opts.Purity = "100"
+	}
+	if opts.Categories == "" {
+		opts.Categories = "111"
+	}
+
+	if !isMask(opts.Purity) || !isMask(opts.Categories) {
+		return nil, ErrInvalidFilter
+	}
+
+	if opts.Purity[1:] != "00" && opts.APIKey == "" {
+		return nil, ErrAPIKeyRequired
+	}
+
+	return &Wallhaven{
+		log:  log.With().Str("component", "wallhaven").Logger(),
+		opts: opts,
+	}, nil
+}
+
+type wallpaper struct {
+	ID         string `json:"id"`
+	URL        string `json:"url"`
+	Path       string `json:"path"`
+	Purity     string `json:"purity"`
+	Category   string `json:"category"`
+	DimensionX int    `json:"dimension_x"`
+	DimensionY int    `json:"dimension_y"`
+}
+
+func (w wallpaper) metadata() searcher.Metadata {
+	return searcher.Metadata{
+		Source:  Name,
+		Title:   w.ID,
+		PageURL: w.URL,
+		Tags:    []string{w.Category, w.Purity},
+		Width:   w.DimensionX,
+		Height:  w.DimensionY,
+	}
+}
+
+type searchResult struct {
+	Data []wallpaper `json:"data"`
+}
+
+func (w *Wallhaven) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, w, q, res)
+}
+
+func (w *Wallhaven) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	items, err := w.fetchSearchResults(ctx, q, res)
+	if err != nil {
+		return nil, err
+	}
+
+	rand.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+
+	candidates := make([]searcher.Candidate, 0, len(items))
+	for _, item := range items {
+		candidates = append(candidates, searcher.Candidate{
+			ID:       item.ID,
+			URL:      item.Path,
+			Width:    item.DimensionX,
+			Height:   item.DimensionY,
+			Metadata: item.metadata(),
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searcher.Download(ctx, &w.client, item.Path)
+			},
+		})
+	}
+
+	return candidates, nil
+}
+
+func (w *Wallhaven) fetchSearchResults(ctx context.Context, q string, res searcher.Resolution) ([]wallpaper, error) {
+	params := url.Values{}
+	params.Set("q", q)
+	params.Set("purity", w.opts.Purity)
+	params.Set("categories", w.opts.Categories)
+	params.Set("sorting", "random")
+	if res.Width > 0 && res.Height > 0 {
+		params.Set("atleast", res.String())
+		params.Set("ratios", closestRatio(res))
+	}
+	if w.opts.APIKey != "" {
+		params.Set("apikey", w.opts.APIKey)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, searchURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	var result searchResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	if len(result.Data) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoResults, q)
+	}
+
+	return result.Data, nil
+}
+
+// ratios are the aspect ratios wallhaven filters by
+var ratios = []struct {
+	name string
+	w, h float64
+}{
+	{"16x9", 16, 9}, {"16x10", 16, 10}, {"21x9", 21, 9}, {"32x9", 32, 9}, {"48x9", 48, 9},
+	{"9x16", 9, 16}, {"10x16", 10, 16}, {"9x18", 9, 18},
+	{"1x1", 1, 1}, {"3x2", 3, 2}, {"4x3", 4, 3}, {"5x4", 5, 4},
+}
+
+// closestRatio maps the target resolution to the nearest wallhaven ratio,
+// exact ratios are ranked locally since odd monitor sizes have no wallhaven equivalent
+func closestRatio(res searcher.Resolution) string {
+	target := float64(res.Width) / float64(res.Height)
+
+	best, bestDist := ratios[0].name, math.Inf(1)
+	for _, r := range ratios {
+		// ratios are compared on a log scale so portrait and landscape distances weigh the same
+		if dist := math.Abs(math.Log(target * r.h / r.w)); dist < bestDist {
+			best, bestDist = r.name, dist
+		}
+	}
+	return best
+}
+
+func isMask(s string) bool {
+	if len(s) != 3 {
+		return false
+	}
+	for _, c := range s {
+		if c != '0' && c != '1' {
+			return false
+		}
+	}
+	return s != "000"
+}
@@ -0,0 +1,36 @@
+package wallhaven
+
+import (
+	"testing"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+)
+
+func TestClosestRatio(t *testing.T) {
+	tests := []struct {
+		w, h int
+		want string
+	}{
+		{1920, 1080, "16x9"},
+		{1366, 768, "16x9"},
+		{1920, 1200, "16x10"},
+		{2560, 1080, "21x9"},
+		{3440, 1440, "21x9"},
+		{5120, 1440, "32x9"},
+		{7680, 1440, "48x9"},
+		{1080, 1920, "9x16"},
+		{1200, 1920, "10x16"},
+		{1080, 2160, "9x18"},
+		{1000, 1000, "1x1"},
+		{2256, 1504, "3x2"},
+		{1024, 768, "4x3"},
+		{1280, 1024, "5x4"},
+	}
+
+	for _, tt := range tests {
+		res := searcher.Resolution{Width: tt.w, Height: tt.h}
+		if got := closestRatio(res); got != tt.want {
+			t.Errorf("closestRatio(%dx%d) = %s, want %s", tt.w, tt.h, got, tt.want)
+		}
+	}
+}
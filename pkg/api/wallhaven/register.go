@@ -0,0 +1,27 @@
+package wallhaven
+
+import (
+	"os"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "wallhaven.cc search",
+		NeedsNetwork: true,
+		Bind: func(fs *flag.FlagSet) searcher.Factory {
+			var opts Options
+			fs.StringVar(&opts.APIKey, "wallhaven-key", os.Getenv("WALLHAVEN_API_KEY"), "wallhaven api key, required for sketchy/nsfw purity")
+			fs.StringVar(&opts.Purity, "wallhaven-purity", "100", "wallhaven sfw/sketchy/nsfw mask")
+			fs.StringVar(&opts.Categories, "wallhaven-categories", "111", "wallhaven general/anime/people mask")
+
+			return func(log zerolog.Logger, _ searcher.Env) (searcher.Searcher, error) {
+				return NewWallhaven(log, opts)
+			}
+		},
+	})
+}
@@ -0,0 +1,238 @@
+package wikimedia
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+)
+
+const Name = "wikimedia"
+
+const (
+	apiURL    = "https://commons.wikimedia.org/w/api.php"
+	userAgent = "chiasma/1.0 (https://github.com/labi-le/chiasma)"
+	limit     = 50
+)
+
+var (
+	ErrNoResults = errors.New("wikimedia: no suitable files")
+
+	htmlTag  = regexp.MustCompile(`<[^>]*>`)
+	hrefAttr = regexp.MustCompile(`href="([^"]+)"`)
+)
+
+type Wikimedia struct {
+	log    zerolog.Logger
+	client http.Client
+	pager  *searcher.Pager
+}
+
+func NewWikimedia(log zerolog.Logger, paging searcher.PageOptions) *Wikimedia {
+	return &Wikimedia{
+		log:    log.With().Str("component", "wikimedia").Logger(),
+		client: searcher.UserAgentClient(userAgent),
+		pager:  searcher.NewPager(Name, paging),
+	}
+}
+
+type metaValue struct {
+	Value string `json:"value"`
+}
+
+type imageInfo struct {
+	URL            string               `json:"url"`
+	DescriptionURL string               `json:"descriptionurl"`
+	Width          int                  `json:"width"`
+	Height         int                  `json:"height"`
+	Mime           string               `json:"mime"`
+	ExtMetadata    map[string]metaValue `json:"extmetadata"`
+}
+
+type page struct {
+	Title     string      `json:"title"`
+	ImageInfo []imageInfo `json:"imageinfo"`
+}
+
+type queryResult struct {
+	// Continue is absent on the last page of results
+	Continue *struct {
+		Offset int `json:"gsroffset"`
+	} `json:"continue"`
+	Query struct {
+		Pages []page `json:"pages"`
+	} `json:"query"`
+}
+
+type candidate struct {
+	title string
+	imageInfo
+}
+
+// metadata collects the license, author and description required to reuse the file
+func (c candidate) metadata() searcher.Metadata {
+	meta := searcher.Metadata{
+		Source:      Name,
+		Title:       strings.TrimPrefix(c.meta("ObjectName", c.title), "File:"),
+		Description: c.meta("ImageDescription", ""),
+		Author:      c.meta("Artist", ""),
+		PageURL:     c.DescriptionURL,
+		License:     c.meta("LicenseShortName", ""),
+		LicenseURL:  c.meta("LicenseUrl", ""),
+		Width:       c.Width,
+		Height:      c.Height,
+	}
+
+	// the artist is usually a link to the author's user page
+	if m := hrefAttr.FindStringSubmatch(c.ExtMetadata["Artist"].Value); m != nil {
+		meta.AuthorURL = m[1]
+		if strings.HasPrefix(meta.AuthorURL, "//") {
+			meta.AuthorURL = "https:" + meta.AuthorURL
+		}
+	}
+
+	for _, category := range strings.Split(c.meta("Categories", ""), "|") {
+		if category != "" {
+			meta.Tags = append(meta.Tags, category)
+		}
+	}
+
+	return meta
+}
+
+// meta returns an extmetadata field as plain text
+func (c candidate) meta(key string, fallback string) string {
+	v, ok := c.ExtMetadata[key]
+	if !ok {
+		return fallback
+	}
+
+	text := strings.Join(strings.Fields(html.UnescapeString(htmlTag.ReplaceAllString(v.Value, ""))), " ")
+	if text == "" {
+		return fallback
+	}
+	return text
+}
+
+func (w *Wikimedia) Search(ctx context.Context, q string, res searcher.Resolution) (searcher.Image, error) {
+	return searcher.SearchCandidates(ctx, w, q, res)
+}
+
+func (w *Wikimedia) Candidates(ctx context.Context, q string, res searcher.Resolution) ([]searcher.Candidate, error) {
+	log := w.log.With().Str("op", "Candidates").Logger()
+
+	pages, err := w.fetchPages(ctx, q, res)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]searcher.Candidate, 0, len(pages))
+	for _, p := range pages {
+		if len(p.ImageInfo) == 0 {
+			continue
+		}
+		info := p.ImageInfo[0]
+
+		if info.Mime != "image/jpeg" && info.Mime != "image/png" {
+			continue
+		}
+
+		file := candidate{title: p.Title, imageInfo: info}
+		candidates = append(candidates, searcher.Candidate{
+			ID:       p.Title,
+			URL:      info.URL,
+			Width:    info.Width,
+			Height:   info.Height,
+			Metadata: file.metadata(),
+			Fetch: func(ctx context.Context) (searcher.Image, error) {
+				return searcher.Download(ctx, &w.client, info.URL)
+			},
+		})
+	}
+
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w among %d results for %s", ErrNoResults, len(pages), q)
+	}
+	log.Info().Int("total", len(pages)).Int("clean", len(candidates)).Msg("filtering complete")
+
+	rand.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+
+	return candidates, nil
+}
+
+func (w *Wikimedia) fetchPages(ctx context.Context, q string, res searcher.Resolution) ([]page, error) {
+	search := q + " filetype:bitmap"
+	if res.Width > 0 && res.Height > 0 {
+		search += fmt.Sprintf(" filew:>%d fileh:>%d", res.Width-1, res.Height-1)
+	}
+
+	key := searcher.PageKey(search)
+	n := w.pager.Page(key)
+
+	pages, err := w.fetchPage(ctx, search, key, n)
+	if err == nil && len(pages) == 0 && n > 1 {
+		w.pager.Empty(key, n)
+		pages, err = w.fetchPage(ctx, search, key, 1)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pages) == 0 {
+		return nil, fmt.Errorf("%w for %s", ErrNoResults, q)
+	}
+
+	return pages, nil
+}
+
+func (w *Wikimedia) fetchPage(ctx context.Context, search string, key string, n int) ([]page, error) {
+	params := url.Values{}
+	params.Set("action", "query")
+	params.Set("format", "json")
+	params.Set("formatversion", "2")
+	params.Set("generator", "search")
+	params.Set("gsrsearch", search)
+	params.Set("gsrnamespace", "6")
+	params.Set("gsrlimit", strconv.Itoa(limit))
+	params.Set("gsroffset", strconv.Itoa((n-1)*limit))
+	params.Set("prop", "imageinfo")
+	params.Set("iiprop", "url|size|mime|extmetadata")
+	params.Set("iiextmetadatafilter", "ObjectName|ImageDescription|Artist|LicenseShortName|LicenseUrl|Categories")
+	params.Set("iiextmetadatalanguage", "en")
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL+"?"+params.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create req: %w", err)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("do search: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("api status: %d", resp.StatusCode)
+	}
+
+	var result queryResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("decode json: %w", err)
+	}
+
+	if result.Continue == nil && len(result.Query.Pages) > 0 {
+		w.pager.SetLast(key, n)
+	}
+
+	return result.Query.Pages, nil
+}
@@ -0,0 +1,36 @@
+package wikimedia
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+func TestCandidateMetadata(t *testing.T) {
+	var info imageInfo
+	err := json.Unmarshal([]byte(`{
+		"url": "https://upload.wikimedia.org/a.jpg",
+		"descriptionurl": "https://commons.wikimedia.org/wiki/File:A.jpg",
+		"width": 4000, "height": 3000,
+		"extmetadata": {
+			"ObjectName": {"value": "Aurora"},
+			"Artist": {"value": "<a href=\"//commons.wikimedia.org/wiki/User:Someone\">Someone</a>"},
+			"LicenseShortName": {"value": "CC BY-SA 4.0"},
+			"Categories": {"value": "Aurora borealis|Night skies in Norway"}
+		}
+	}`), &info)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	meta := candidate{title: "File:A.jpg", imageInfo: info}.metadata()
+	if meta.Title != "Aurora" || meta.Author != "Someone" || meta.License != "CC BY-SA 4.0" {
+		t.Errorf("metadata = %+v", meta)
+	}
+	if meta.AuthorURL != "https://commons.wikimedia.org/wiki/User:Someone" {
+		t.Errorf("AuthorURL = %q", meta.AuthorURL)
+	}
+	if want := []string{"Aurora borealis", "Night skies in Norway"}; !slices.Equal(meta.Tags, want) {
+		t.Errorf("Tags = %q, want %q", meta.Tags, want)
+	}
+}
@@ -0,0 +1,20 @@
+package wikimedia
+
+import (
+	"github.com/labi-le/chiasma/pkg/api/searcher"
+	"github.com/rs/zerolog"
+	flag "github.com/spf13/pflag"
+)
+
+func init() {
+	searcher.Register(searcher.Registration{
+		Name:         Name,
+		Description:  "wikimedia commons originals with license metadata",
+		NeedsNetwork: true,
+		Bind: func(*flag.FlagSet) searcher.Factory {
+			return func(log zerolog.Logger, env searcher.Env) (searcher.Searcher, error) {
+				return NewWikimedia(log, env.Paging), nil
+			}
+		},
+	})
+}
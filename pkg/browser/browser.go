@@ -1,8 +1,8 @@
 package browser
 
 var (
-	ChromiumBasedBrowsers = []string{"google-chrome", "vivaldi", "chromium", "brave", "opera"}
-	FirefoxBasedBrowsers  = []string{"firefox"}
+	ChromiumBasedBrowsers = []string{"google-chrome", "vivaldi", "chromium", "brave", "opera", "microsoft-edge"}
+	FirefoxBasedBrowsers  = []string{"firefox", "librewolf", "waterfox", "floorp", "zen"}
 )
 
 func AvailableBrowsers() []string {
@@ -17,3 +17,12 @@ func IsChromiumBased(browser string) bool {
 	}
 	return false
 }
+
+func IsFirefoxBased(browser string) bool {
+	for _, b := range FirefoxBasedBrowsers {
+		if browser == b {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+const (
+	// webDataFile holds the configured search engines next to the History file
+	webDataFile = "Web Data"
+
+	// chromiumEpochOffset is the number of microseconds between 1601-01-01 and the unix epoch
+	chromiumEpochOffset = 11644473600000000
+)
+
+type chromiumHistory struct {
+	db      *sql.DB
+	engines []Engine
+	// shortNames are the engine names of keyword ids from Web Data
+	shortNames map[int64]string
+	only       engineFilter
+}
+
+func (h *chromiumHistory) Close() error { return h.db.Close() }
+func (h *chromiumHistory) GetLastSearch() (string, error) {
+	s, err := h.LastSearch()
+	return s.Query, err
+}
+
+// LastSearch prefers the omnibox searches chromium records per engine,
+// falling back to result page urls of known engines
+func (h *chromiumHistory) LastSearch() (Search, error) {
+	s, err := h.keywordSearch()
+	if err == nil {
+		return s, nil
+	}
+	return h.urlSearch()
+}
+
+// keywordSearch reads keyword_search_terms, which covers every configured engine
+func (h *chromiumHistory) keywordSearch() (Search, error) {
+	rows, err := h.db.Query(`
+		SELECT k.term, k.keyword_id, u.url, u.last_visit_time
+		FROM keyword_search_terms k JOIN urls u ON u.id = k.url_id
+		ORDER BY u.last_visit_time DESC LIMIT ?
+	`, recentSearchURLs)
+	if err != nil {
+		return Search{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			term      string
+			keywordID int64
+			u         string
+			visited   int64
+		)
+		if err := rows.Scan(&term, &keywordID, &u, &visited); err != nil {
+			return Search{}, err
+		}
+
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		e, _, _ := matchEngine(h.engines, u)
+		shortName := h.shortNames[keywordID]
+		if !h.only.allows(e.Name, shortName) {
+			continue
+		}
+
+		name := e.Name
+		if name == "" {
+			name = shortName
+		}
+		return Search{Query: term, Engine: name, Time: chromiumTime(visited)}, nil
+	}
+	if err := rows.Err(); err != nil {
+		return Search{}, err
+	}
+	return Search{}, ErrHistoryIsEmpty
+}
+
+// urlSearch parses the result page urls of the engine table
+func (h *chromiumHistory) urlSearch() (Search, error) {
+	return urlSearch(h.db, `
+		SELECT url, last_visit_time FROM urls
+		WHERE %s
+		ORDER BY last_visit_time DESC LIMIT ?
+	`, h.engines, h.only, chromiumTime)
+}
+
+// loadShortNames reads the engine names from Web Data, a missing or locked file only loses the names
+func loadShortNames(path string) map[int64]string {
+	names := make(map[int64]string)
+
+	db, err := openDB(path)
+	if err != nil {
+		return names
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`SELECT id, short_name FROM keywords`)
+	if err != nil {
+		return names
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			id   int64
+			name string
+		)
+		if err := rows.Scan(&id, &name); err != nil {
+			break
+		}
+		names[id] = name
+	}
+	return names
+}
+
+// chromiumTime converts microseconds since 1601-01-01 to a time
+func chromiumTime(us int64) time.Time {
+	if us == 0 {
+		return time.Time{}
+	}
+	return time.UnixMicro(us - chromiumEpochOffset)
+}
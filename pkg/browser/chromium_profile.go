@@ -0,0 +1,145 @@
+package browser
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// localStateFile lists the profiles of a chromium user data directory
+	localStateFile = "Local State"
+	historyFile    = "History"
+	defaultProfile = "Default"
+)
+
+// chromiumRoots are the user data directories of chromium-based browsers, probed by probeRoots
+var chromiumRoots = map[string][]string{
+	"google-chrome": {
+		".config/google-chrome",
+		".var/app/com.google.Chrome/config/google-chrome",
+	},
+	"chromium": {
+		".config/chromium",
+		".var/app/org.chromium.Chromium/config/chromium",
+		"snap/chromium/common/chromium",
+	},
+	"vivaldi": {
+		".config/vivaldi",
+		".var/app/com.vivaldi.Vivaldi/config/vivaldi",
+	},
+	"brave": {
+		".config/BraveSoftware/Brave-Browser",
+		".var/app/com.brave.Browser/config/BraveSoftware/Brave-Browser",
+		"snap/brave/current/.config/BraveSoftware/Brave-Browser",
+	},
+	// opera keeps the only profile in the user data directory itself
+	"opera": {
+		".config/opera",
+		".var/app/com.opera.Opera/config/opera",
+		"snap/opera/current/.config/opera",
+	},
+	"microsoft-edge": {
+		".config/microsoft-edge",
+		".var/app/com.microsoft.Edge/config/microsoft-edge",
+	},
+}
+
+type localState struct {
+	Profile struct {
+		InfoCache map[string]struct {
+			Name string `json:"name"`
+		} `json:"info_cache"`
+		LastUsed string `json:"last_used"`
+	} `json:"profile"`
+}
+
+// chromiumProfiles returns the History file of the named profile, the last used one when name is empty
+// and every profile for AllProfiles
+func chromiumProfiles(browser string, name string) ([]string, error) {
+	roots, ok := chromiumRoots[browser]
+	if !ok {
+		roots = []string{filepath.Join(".config", browser)}
+	}
+
+	return probeRoots(browser+" user data directory", roots, func(root string) ([]string, error) {
+		if !exists(root) {
+			return nil, os.ErrNotExist
+		}
+		return pickChromiumProfiles(root, name)
+	})
+}
+
+// pickChromiumProfiles matches name against the profile directories and display names of Local State
+func pickChromiumProfiles(root string, name string) ([]string, error) {
+	var state localState
+	if data, err := os.ReadFile(filepath.Join(root, localStateFile)); err == nil {
+		_ = json.Unmarshal(data, &state)
+	}
+
+	dirs := make([]string, 0, len(state.Profile.InfoCache))
+	for dir := range state.Profile.InfoCache {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var (
+		picked []string
+		// inRoot allows the profile of browsers keeping history in the user data directory
+		inRoot bool
+	)
+	switch name {
+	case AllProfiles:
+		if len(dirs) == 0 {
+			dirs = profileDirs(root)
+		}
+		picked, inRoot = dirs, true
+	case "":
+		last := state.Profile.LastUsed
+		if last == "" {
+			last = defaultProfile
+		}
+		picked, inRoot = []string{last}, true
+	default:
+		for _, dir := range dirs {
+			if dir == name || strings.EqualFold(state.Profile.InfoCache[dir].Name, name) {
+				picked = append(picked, dir)
+				break
+			}
+		}
+		if len(picked) == 0 && exists(filepath.Join(root, name, historyFile)) {
+			picked = append(picked, name)
+		}
+	}
+
+	var paths []string
+	for _, dir := range picked {
+		if path := filepath.Join(root, dir, historyFile); exists(path) {
+			paths = append(paths, path)
+		}
+	}
+	if path := filepath.Join(root, historyFile); inRoot && exists(path) {
+		paths = append(paths, path)
+	}
+
+	if len(paths) == 0 {
+		if name == "" {
+			name = defaultProfile
+		}
+		return nil, fmt.Errorf("%w: %s in %s", ErrProfileNotFound, name, root)
+	}
+	return paths, nil
+}
+
+// profileDirs guesses the profile directories when Local State does not list them
+func profileDirs(root string) []string {
+	dirs := []string{defaultProfile}
+	matches, _ := filepath.Glob(filepath.Join(root, "Profile *"))
+	for _, m := range matches {
+		dirs = append(dirs, filepath.Base(m))
+	}
+	return dirs
+}
@@ -0,0 +1,114 @@
+package browser
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testLocalState = `{"profile": {
+	"info_cache": {
+		"Default": {"name": "Personal"},
+		"Profile 1": {"name": "Work"},
+		"Profile 2": {"name": "Unused"}
+	},
+	"last_used": "Profile 1"
+}}`
+
+func TestPickChromiumProfiles(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, localStateFile), testLocalState)
+	for _, dir := range []string{"Default", "Profile 1", "Guest Profile"} {
+		writeFile(t, filepath.Join(root, dir, historyFile), "")
+	}
+	history := func(dir string) string { return filepath.Join(root, dir, historyFile) }
+
+	tests := []struct {
+		name    string
+		profile string
+		want    []string
+		err     error
+	}{
+		{"last used", "", []string{history("Profile 1")}, nil},
+		{"by directory", "Default", []string{history("Default")}, nil},
+		{"by display name", "work", []string{history("Profile 1")}, nil},
+		{"not in local state", "Guest Profile", []string{history("Guest Profile")}, nil},
+		{"all skips profiles without history", AllProfiles, []string{history("Default"), history("Profile 1")}, nil},
+		{"without history", "Unused", nil, ErrProfileNotFound},
+		{"unknown", "missing", nil, ErrProfileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickChromiumProfiles(root, tt.profile)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("pickChromiumProfiles error = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pickChromiumProfiles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPickChromiumProfilesWithoutLocalState(t *testing.T) {
+	root := t.TempDir()
+	for _, dir := range []string{defaultProfile, "Profile 1", "Profile 3"} {
+		writeFile(t, filepath.Join(root, dir, historyFile), "")
+	}
+	history := func(dir string) string { return filepath.Join(root, dir, historyFile) }
+
+	got, err := pickChromiumProfiles(root, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{history(defaultProfile)}; !slices.Equal(got, want) {
+		t.Errorf("pickChromiumProfiles = %q, want %q", got, want)
+	}
+
+	got, err = pickChromiumProfiles(root, AllProfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{history(defaultProfile), history("Profile 1"), history("Profile 3")}; !slices.Equal(got, want) {
+		t.Errorf("pickChromiumProfiles(%q) = %q, want %q", AllProfiles, got, want)
+	}
+}
+
+// opera keeps its only profile in the user data directory
+func TestPickChromiumProfilesInRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, historyFile), "")
+
+	for _, profile := range []string{"", AllProfiles} {
+		got, err := pickChromiumProfiles(root, profile)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := []string{filepath.Join(root, historyFile)}; !slices.Equal(got, want) {
+			t.Errorf("pickChromiumProfiles(%q) = %q, want %q", profile, got, want)
+		}
+	}
+}
+
+func TestChromiumProfilesVendorPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// only the flatpak install exists
+	root := filepath.Join(home, ".var/app/com.brave.Browser/config/BraveSoftware/Brave-Browser")
+	writeFile(t, filepath.Join(root, defaultProfile, historyFile), "")
+
+	got, err := chromiumProfiles("brave", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(root, defaultProfile, historyFile)}; !slices.Equal(got, want) {
+		t.Errorf("chromiumProfiles = %q, want %q", got, want)
+	}
+
+	if _, err := chromiumProfiles("vivaldi", ""); err == nil {
+		t.Error("chromiumProfiles found a profile of a browser that is not installed")
+	}
+}
@@ -0,0 +1,131 @@
+package browser
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+)
+
+// queryPlaceholder marks the query parameter in user-defined engine urls
+const queryPlaceholder = "{query}"
+
+var (
+	ErrInvalidEngine = errors.New("search engine url must be http(s) with a {query} parameter")
+)
+
+// Engine describes how a search engine puts the query into its result urls
+type Engine struct {
+	Name string
+	// Hosts are host globs as in path.Match, e.g. "www.google.*" also matches "www.google.co.uk"
+	Hosts []string
+	// Path is the result page path prefix, empty matches any path
+	Path string
+	// Param is the query parameter holding the search terms
+	Param string
+}
+
+// DefaultEngines are the search engines recognised without configuration
+var DefaultEngines = []Engine{
+	{Name: "google", Hosts: []string{"www.google.*", "google.*"}, Path: "/search", Param: "q"},
+	{Name: "duckduckgo", Hosts: []string{"duckduckgo.com", "html.duckduckgo.com", "lite.duckduckgo.com"}, Param: "q"},
+	{Name: "bing", Hosts: []string{"www.bing.com", "bing.com"}, Path: "/search", Param: "q"},
+	{Name: "yandex", Hosts: []string{"yandex.*", "ya.ru"}, Path: "/search", Param: "text"},
+	{Name: "kagi", Hosts: []string{"kagi.com"}, Path: "/search", Param: "q"},
+	{Name: "startpage", Hosts: []string{"www.startpage.com", "startpage.com"}, Param: "query"},
+	{Name: "ecosia", Hosts: []string{"www.ecosia.org"}, Path: "/search", Param: "q"},
+	{Name: "brave", Hosts: []string{"search.brave.com"}, Path: "/search", Param: "q"},
+	// searxng instances are self-hosted under any domain, the glob only catches the ones named after it,
+	// others are added with --search-engine since /search?q= alone also matches unrelated site searches
+	{Name: "searxng", Hosts: []string{"*searx*"}, Path: "/search", Param: "q"},
+}
+
+// ParseEngine builds an engine from a result url with a {query} parameter,
+// e.g. https://search.example.org/search?q={query}, the host may be a glob
+func ParseEngine(name string, pattern string) (Engine, error) {
+	u, err := url.Parse(pattern)
+	if err != nil {
+		return Engine{}, fmt.Errorf("search engine %s: %w", name, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Engine{}, fmt.Errorf("%w: %s=%s", ErrInvalidEngine, name, pattern)
+	}
+
+	for param, values := range u.Query() {
+		for _, v := range values {
+			if v == queryPlaceholder {
+				return Engine{Name: name, Hosts: []string{u.Host}, Path: u.Path, Param: param}, nil
+			}
+		}
+	}
+	return Engine{}, fmt.Errorf("%w: %s=%s", ErrInvalidEngine, name, pattern)
+}
+
+// Engines returns the user-defined engines sorted by name followed by the default ones,
+// so custom patterns win over the built-in table
+func Engines(custom map[string]string) ([]Engine, error) {
+	names := make([]string, 0, len(custom))
+	for name := range custom {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	engines := make([]Engine, 0, len(custom)+len(DefaultEngines))
+	for _, name := range names {
+		e, err := ParseEngine(name, custom[name])
+		if err != nil {
+			return nil, err
+		}
+		engines = append(engines, e)
+	}
+	return append(engines, DefaultEngines...), nil
+}
+
+// Query returns the search terms of rawURL when it is a result page of the engine
+func (e Engine) Query(rawURL string) (string, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return "", false
+	}
+	if !strings.HasPrefix(u.Path, e.Path) || !e.matchHost(u.Hostname()) {
+		return "", false
+	}
+
+	q := strings.TrimSpace(u.Query().Get(e.Param))
+	return q, q != ""
+}
+
+func (e Engine) matchHost(host string) bool {
+	host = strings.ToLower(host)
+	for _, pattern := range e.Hosts {
+		if ok, _ := path.Match(strings.ToLower(pattern), host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// likePatterns returns sql LIKE patterns preselecting the result urls of the engine,
+// matches are confirmed with Query
+func (e Engine) likePatterns() []string {
+	escape := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+	patterns := make([]string, 0, len(e.Hosts))
+	for _, host := range e.Hosts {
+		host = strings.ReplaceAll(escape.Replace(host), "*", "%")
+		patterns = append(patterns, "http%://"+host+escape.Replace(e.Path)+"%"+escape.Replace(e.Param)+"=%")
+	}
+	return patterns
+}
+
+// matchEngine returns the first engine rawURL is a result page of
+func matchEngine(engines []Engine, rawURL string) (Engine, string, bool) {
+	for _, e := range engines {
+		if q, ok := e.Query(rawURL); ok {
+			return e, q, true
+		}
+	}
+	return Engine{}, "", false
+}
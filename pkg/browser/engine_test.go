@@ -0,0 +1,166 @@
+package browser
+
+import (
+	"database/sql"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParseEngine(t *testing.T) {
+	tests := []struct {
+		pattern string
+		want    Engine
+		err     error
+	}{
+		{
+			pattern: "https://search.example.org/search?q={query}",
+			want:    Engine{Name: "e", Hosts: []string{"search.example.org"}, Path: "/search", Param: "q"},
+		},
+		{
+			pattern: "https://search.*.org/?lang=en&text={query}",
+			want:    Engine{Name: "e", Hosts: []string{"search.*.org"}, Path: "/", Param: "text"},
+		},
+		{pattern: "search.example.org/search?q={query}", err: ErrInvalidEngine},
+		{pattern: "ftp://search.example.org/search?q={query}", err: ErrInvalidEngine},
+		{pattern: "https://search.example.org/search?q=terms", err: ErrInvalidEngine},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern, func(t *testing.T) {
+			got, err := ParseEngine("e", tt.pattern)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("ParseEngine error = %v, want %v", err, tt.err)
+			}
+			if err == nil && (got.Name != tt.want.Name || !slices.Equal(got.Hosts, tt.want.Hosts) ||
+				got.Path != tt.want.Path || got.Param != tt.want.Param) {
+				t.Errorf("ParseEngine = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnginesCustomFirst(t *testing.T) {
+	engines, err := Engines(map[string]string{
+		"zeta":  "https://z.example.org/?q={query}",
+		"alpha": "https://a.example.org/?q={query}",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if engines[0].Name != "alpha" || engines[1].Name != "zeta" || len(engines) != 2+len(DefaultEngines) {
+		t.Errorf("Engines = %v", engines)
+	}
+
+	if _, err := Engines(map[string]string{"bad": "https://example.org/"}); !errors.Is(err, ErrInvalidEngine) {
+		t.Errorf("Engines error = %v, want %v", err, ErrInvalidEngine)
+	}
+}
+
+func TestEngineQuery(t *testing.T) {
+	tests := []struct {
+		url    string
+		engine string
+		query  string
+	}{
+		{"https://www.google.com/search?q=mountain+lake&hl=en", "google", "mountain lake"},
+		{"https://www.google.co.uk/search?q=fjord", "google", "fjord"},
+		{"https://duckduckgo.com/?q=aurora&ia=images", "duckduckgo", "aurora"},
+		{"https://yandex.ru/search/?text=%D0%BB%D0%B5%D1%81", "yandex", "лес"},
+		{"https://searx.example.org/search?q=desert", "searxng", "desert"},
+		{"https://WWW.BING.COM/search?q=canyon", "bing", "canyon"},
+		// not result pages
+		{"https://www.google.com/maps?q=berlin", "", ""},
+		{"https://www.google.com/search?q=++", "", ""},
+		{"https://example.org/search?q=forest", "", ""},
+		{"file:///search?q=forest", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			e, q, ok := matchEngine(DefaultEngines, tt.url)
+			if ok != (tt.engine != "") || e.Name != tt.engine || q != tt.query {
+				t.Errorf("matchEngine = %s %q %v, want %s %q", e.Name, q, ok, tt.engine, tt.query)
+			}
+		})
+	}
+}
+
+func TestLikePatterns(t *testing.T) {
+	e := Engine{Name: "e", Hosts: []string{"search.*.org", "my_search.org"}, Path: "/find", Param: "q"}
+	want := []string{`http%://search.%.org/find%q=%`, `http%://my\_search.org/find%q=%`}
+	if got := e.likePatterns(); !slices.Equal(got, want) {
+		t.Errorf("likePatterns = %q, want %q", got, want)
+	}
+
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	like := func(url string) bool {
+		var n int
+		for _, pattern := range e.likePatterns() {
+			var m int
+			if err := db.QueryRow(`SELECT ? LIKE ? ESCAPE '\'`, url, pattern).Scan(&m); err != nil {
+				t.Fatal(err)
+			}
+			n += m
+		}
+		return n > 0
+	}
+
+	for url, want := range map[string]bool{
+		"https://search.example.org/find?q=forest":    true,
+		"http://my_search.org/find?lang=en&q=forest":  true,
+		"https://myxsearch.org/find?q=forest":         false,
+		"https://search.example.org/other?q=forest":   false,
+		"https://search.example.org/find?text=forest": false,
+	} {
+		if got := like(url); got != want {
+			t.Errorf("LIKE %s = %v, want %v", url, got, want)
+		}
+		if _, _, ok := matchEngine([]Engine{e}, url); want && !ok {
+			t.Errorf("%s preselected but not matched", url)
+		}
+	}
+}
+
+func TestURLSearch(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE urls (url TEXT, last_visit_time INTEGER);
+		INSERT INTO urls VALUES
+			('https://www.google.com/search?q=older', 1),
+			('https://duckduckgo.com/?q=newest', 3),
+			('https://example.org/?q=ignored', 4),
+			('https://www.bing.com/search?q=middle', 2);
+	`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := `SELECT url, last_visit_time FROM urls WHERE %s ORDER BY last_visit_time DESC LIMIT ?`
+	visit := func(v int64) time.Time { return time.Unix(v, 0) }
+
+	s, err := urlSearch(db, query, DefaultEngines, nil, visit)
+	if err != nil || s.Query != "newest" || s.Engine != "duckduckgo" || !s.Time.Equal(time.Unix(3, 0)) {
+		t.Errorf("urlSearch = %+v, %v", s, err)
+	}
+
+	s, err = urlSearch(db, query, DefaultEngines, engineFilter{"Google", "bing"}, visit)
+	if err != nil || s.Query != "middle" || s.Engine != "bing" {
+		t.Errorf("urlSearch with filter = %+v, %v", s, err)
+	}
+
+	if _, err := urlSearch(db, query, DefaultEngines, engineFilter{"kagi"}, visit); !errors.Is(err, ErrHistoryIsEmpty) {
+		t.Errorf("urlSearch error = %v, want %v", err, ErrHistoryIsEmpty)
+	}
+}
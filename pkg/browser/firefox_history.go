@@ -0,0 +1,126 @@
+package browser
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// formHistoryFile holds the search bar history of a profile
+	formHistoryFile = "formhistory.sqlite"
+	// placesFile holds the visited urls of a profile
+	placesFile = "places.sqlite"
+)
+
+// firefoxHistory reads both the search bar and the address bar searches of a profile,
+// either database may be missing
+type firefoxHistory struct {
+	forms   *sql.DB
+	places  *sql.DB
+	engines []Engine
+	only    engineFilter
+}
+
+// openFirefoxHistory opens the databases of the profile path points into,
+// path is the profile directory or one of its files
+func openFirefoxHistory(path string, engines []Engine, only engineFilter) (History, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	dir, forms, places := path, filepath.Join(path, formHistoryFile), filepath.Join(path, placesFile)
+	if !info.IsDir() {
+		dir = filepath.Dir(path)
+		forms, places = path, filepath.Join(dir, placesFile)
+		if filepath.Base(path) == placesFile {
+			forms, places = filepath.Join(dir, formHistoryFile), path
+		}
+	}
+
+	h := &firefoxHistory{engines: engines, only: only}
+	if exists(forms) {
+		if h.forms, err = openDB(forms); err != nil {
+			return nil, err
+		}
+	}
+	if exists(places) {
+		if h.places, err = openDB(places); err != nil {
+			_ = h.Close()
+			return nil, err
+		}
+	}
+
+	if h.forms == nil && h.places == nil {
+		return nil, fmt.Errorf("no %s or %s in %s", formHistoryFile, placesFile, dir)
+	}
+	return h, nil
+}
+
+func (h *firefoxHistory) Close() error {
+	var errs []error
+	for _, db := range []*sql.DB{h.forms, h.places} {
+		if db != nil {
+			errs = append(errs, db.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (h *firefoxHistory) GetLastSearch() (string, error) {
+	s, err := h.LastSearch()
+	return s.Query, err
+}
+
+// LastSearch returns the most recent of the search bar and address bar searches
+func (h *firefoxHistory) LastSearch() (Search, error) {
+	return mostRecent(h.formSearch, h.placesSearch)
+}
+
+// formSearch reads the search bar, which does not record the engine
+func (h *firefoxHistory) formSearch() (Search, error) {
+	if h.forms == nil || !h.only.allows() {
+		return Search{}, ErrHistoryIsEmpty
+	}
+
+	var (
+		value    string
+		lastUsed int64
+	)
+	err := h.forms.QueryRow(`
+		SELECT value, lastUsed FROM moz_formhistory
+		WHERE fieldname = 'searchbar-history'
+		ORDER BY lastUsed DESC LIMIT 1
+	`).Scan(&value, &lastUsed)
+
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return Search{}, ErrHistoryIsEmpty
+		}
+		return Search{}, err
+	}
+	return Search{Query: value, Time: time.UnixMicro(lastUsed)}, nil
+}
+
+// placesSearch parses the result page urls of address bar searches
+func (h *firefoxHistory) placesSearch() (Search, error) {
+	if h.places == nil {
+		return Search{}, ErrHistoryIsEmpty
+	}
+
+	return urlSearch(h.places, `
+		SELECT p.url, v.visit_date FROM moz_historyvisits v
+		JOIN moz_places p ON p.id = v.place_id
+		WHERE %s
+		ORDER BY v.visit_date DESC LIMIT ?
+	`, h.engines, h.only, time.UnixMicro)
+}
+
+func exists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
@@ -0,0 +1,150 @@
+package browser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	profilesFile = "profiles.ini"
+	installsFile = "installs.ini"
+)
+
+var (
+	ErrProfileNotFound = errors.New("browser profile not found")
+)
+
+// firefoxRoots are the profile directories of firefox-based browsers, probed by probeRoots
+var firefoxRoots = map[string][]string{
+	"firefox": {
+		".mozilla/firefox",
+		".var/app/org.mozilla.firefox/.mozilla/firefox",
+		"snap/firefox/common/.mozilla/firefox",
+	},
+	"librewolf": {
+		".librewolf",
+		".var/app/io.gitlab.librewolf-community/.librewolf",
+	},
+	"waterfox": {
+		".waterfox",
+		".var/app/net.waterfox.waterfox/.waterfox",
+	},
+	"floorp": {
+		".floorp",
+		".var/app/one.ablaze.floorp/.floorp",
+	},
+	"zen": {
+		".zen",
+		".var/app/app.zen_browser.zen/.zen",
+	},
+}
+
+type iniSection struct {
+	Name string
+	Keys map[string]string
+}
+
+// firefoxProfiles returns the directory of the named profile, the default one when name is empty
+// and every profile for AllProfiles
+func firefoxProfiles(browser string, name string) ([]string, error) {
+	return probeRoots(browser+" "+profilesFile, firefoxRoots[browser], func(root string) ([]string, error) {
+		sections, err := parseINI(filepath.Join(root, profilesFile))
+		if err != nil {
+			return nil, err
+		}
+		// installs.ini is optional, its sections are the install sections of profiles.ini without the prefix
+		installs, _ := parseINI(filepath.Join(root, installsFile))
+		for i := range installs {
+			installs[i].Name = "Install" + installs[i].Name
+		}
+
+		return pickProfiles(root, append(installs, sections...), name)
+	})
+}
+
+// pickProfiles finds the named profile, or the default one: the profile the install uses,
+// then the profile marked default, then the first one
+func pickProfiles(root string, sections []iniSection, name string) ([]string, error) {
+	var (
+		profiles []iniSection
+		install  string
+	)
+	for _, s := range sections {
+		switch {
+		case strings.HasPrefix(s.Name, "Profile"):
+			profiles = append(profiles, s)
+		case strings.HasPrefix(s.Name, "Install") && install == "":
+			install = s.Keys["Default"]
+		}
+	}
+
+	dir := func(p iniSection) string {
+		path := p.Keys["Path"]
+		if p.Keys["IsRelative"] != "0" {
+			path = filepath.Join(root, path)
+		}
+		return path
+	}
+
+	if len(profiles) == 0 {
+		return nil, fmt.Errorf("%w: no profiles in %s", ErrProfileNotFound, root)
+	}
+
+	switch name {
+	case AllProfiles:
+		dirs := make([]string, 0, len(profiles))
+		for _, p := range profiles {
+			dirs = append(dirs, dir(p))
+		}
+		return dirs, nil
+	case "":
+	default:
+		for _, p := range profiles {
+			if p.Keys["Name"] == name || p.Keys["Path"] == name {
+				return []string{dir(p)}, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: %s in %s", ErrProfileNotFound, name, root)
+	}
+
+	for _, p := range profiles {
+		if install != "" && p.Keys["Path"] == install {
+			return []string{dir(p)}, nil
+		}
+	}
+	for _, p := range profiles {
+		if p.Keys["Default"] == "1" {
+			return []string{dir(p)}, nil
+		}
+	}
+	return []string{dir(profiles[0])}, nil
+}
+
+// parseINI reads the sections of an ini file in order
+func parseINI(path string) ([]iniSection, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var sections []iniSection
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "", strings.HasPrefix(line, ";"), strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]"):
+			sections = append(sections, iniSection{Name: line[1 : len(line)-1], Keys: make(map[string]string)})
+		case len(sections) > 0:
+			if k, v, ok := strings.Cut(line, "="); ok {
+				sections[len(sections)-1].Keys[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return sections, scanner.Err()
+}
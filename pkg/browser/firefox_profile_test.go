@@ -0,0 +1,152 @@
+package browser
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const testProfilesINI = `; written by firefox
+[Install4F96D1932A9F858E]
+Default=abcd.work
+Locked=1
+
+[Profile1]
+Name=work
+IsRelative=1
+Path=abcd.work
+
+[Profile0]
+Name=default
+IsRelative=1
+Path=wxyz.default
+Default=1
+
+[Profile2]
+Name=portable
+IsRelative=0
+Path=/mnt/usb/firefox
+
+[General]
+StartWithLastProfile=1
+Version=2
+`
+
+func writeFile(t *testing.T, path string, data string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestParseINI(t *testing.T) {
+	path := filepath.Join(t.TempDir(), profilesFile)
+	writeFile(t, path, testProfilesINI)
+
+	sections, err := parseINI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	for _, s := range sections {
+		names = append(names, s.Name)
+	}
+	if want := []string{"Install4F96D1932A9F858E", "Profile1", "Profile0", "Profile2", "General"}; !slices.Equal(names, want) {
+		t.Fatalf("sections = %q, want %q", names, want)
+	}
+	if s := sections[2]; s.Keys["Path"] != "wxyz.default" || s.Keys["Default"] != "1" {
+		t.Errorf("Profile0 keys = %v", s.Keys)
+	}
+
+	if _, err := parseINI(filepath.Join(t.TempDir(), "missing.ini")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("parseINI error = %v, want %v", err, os.ErrNotExist)
+	}
+}
+
+func TestPickProfiles(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, profilesFile)
+	writeFile(t, path, testProfilesINI)
+	sections, err := parseINI(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutInstall := sections[1:]
+
+	tests := []struct {
+		name     string
+		sections []iniSection
+		profile  string
+		want     []string
+		err      error
+	}{
+		{"install default", sections, "", []string{filepath.Join(root, "abcd.work")}, nil},
+		{"marked default", withoutInstall, "", []string{filepath.Join(root, "wxyz.default")}, nil},
+		{"first profile", withoutInstall[:1], "", []string{filepath.Join(root, "abcd.work")}, nil},
+		{"by name", sections, "default", []string{filepath.Join(root, "wxyz.default")}, nil},
+		{"by path", sections, "wxyz.default", []string{filepath.Join(root, "wxyz.default")}, nil},
+		{"absolute", sections, "portable", []string{"/mnt/usb/firefox"}, nil},
+		{"all", sections, AllProfiles, []string{
+			filepath.Join(root, "abcd.work"), filepath.Join(root, "wxyz.default"), "/mnt/usb/firefox",
+		}, nil},
+		{"unknown", sections, "missing", nil, ErrProfileNotFound},
+		{"no profiles", sections[4:], "", nil, ErrProfileNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pickProfiles(root, tt.sections, tt.profile)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("pickProfiles error = %v, want %v", err, tt.err)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("pickProfiles = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirefoxProfilesInstallsINI(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	root := filepath.Join(home, ".librewolf")
+	writeFile(t, filepath.Join(root, profilesFile), "[Profile0]\nName=a\nPath=a.default\nDefault=1\n\n[Profile1]\nName=b\nPath=b.release\n")
+	writeFile(t, filepath.Join(root, installsFile), "[4F96D1932A9F858E]\nDefault=b.release\n")
+
+	got, err := firefoxProfiles("librewolf", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(root, "b.release")}; !slices.Equal(got, want) {
+		t.Errorf("firefoxProfiles = %q, want the installs.ini default %q", got, want)
+	}
+
+	if _, err := firefoxProfiles("zen", ""); err == nil {
+		t.Error("firefoxProfiles found a profile of a browser that is not installed")
+	}
+}
+
+func TestFirefoxProfilesVendorPaths(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	// only the snap install exists
+	root := filepath.Join(home, "snap/firefox/common/.mozilla/firefox")
+	writeFile(t, filepath.Join(root, profilesFile), "[Profile0]\nName=default\nPath=x.default\nDefault=1\n")
+	writeFile(t, filepath.Join(root, "x.default", placesFile), "")
+
+	got, err := firefoxProfiles("firefox", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{filepath.Join(root, "x.default")}; !slices.Equal(got, want) {
+		t.Errorf("firefoxProfiles = %q, want %q", got, want)
+	}
+}
@@ -4,86 +4,262 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
-	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	_ "modernc.org/sqlite"
 )
 
+// AllProfiles selects every profile of a browser, the most recent search wins
+const AllProfiles = "all"
+
 var ErrHistoryIsEmpty = errors.New("browser history is empty")
 
 type History interface {
 	GetLastSearch() (string, error)
+	// LastSearch is GetLastSearch with the engine and time when the history records them
+	LastSearch() (Search, error)
 	Close() error
 }
 
-type chromiumHistory struct{ db *sql.DB }
+// Search is a query found in the browser history
+type Search struct {
+	Query string
+	// Engine is the search engine name, empty when the history does not tell
+	Engine string
+	Time   time.Time
+}
 
-func (h *chromiumHistory) Close() error { return h.db.Close() }
-func (h *chromiumHistory) GetLastSearch() (string, error) {
-	var lastURL string
-	err := h.db.QueryRow(`
-		SELECT url FROM urls
-		WHERE url LIKE 'https://www.google.com/search?%'
-		ORDER BY last_visit_time DESC LIMIT 1
-	`).Scan(&lastURL)
+// engineFilter restricts searches to the named engines, empty allows any
+type engineFilter []string
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", ErrHistoryIsEmpty
+// allows reports whether any of the names of a search engine is in the filter
+func (f engineFilter) allows(names ...string) bool {
+	if len(f) == 0 {
+		return true
+	}
+	for _, want := range f {
+		for _, name := range names {
+			if name != "" && strings.EqualFold(strings.TrimSpace(want), name) {
+				return true
+			}
 		}
-		return "", err
 	}
-	u, err := url.Parse(lastURL)
-	if err != nil {
-		return "", err
+	return false
+}
+
+// multiHistory reads several profiles, the most recent search wins
+type multiHistory []History
+
+func (h multiHistory) Close() error {
+	errs := make([]error, 0, len(h))
+	for _, history := range h {
+		errs = append(errs, history.Close())
 	}
-	return u.Query().Get("q"), nil
+	return errors.Join(errs...)
 }
 
-type firefoxHistory struct{ db *sql.DB }
+func (h multiHistory) GetLastSearch() (string, error) {
+	s, err := h.LastSearch()
+	return s.Query, err
+}
 
-func (h *firefoxHistory) Close() error { return h.db.Close() }
-func (h *firefoxHistory) GetLastSearch() (string, error) {
-	var value string
-	err := h.db.QueryRow(`
-		SELECT value FROM moz_formhistory
-		WHERE fieldname = 'searchbar-history'
-		ORDER BY lastUsed DESC LIMIT 1
-	`).Scan(&value)
+func (h multiHistory) LastSearch() (Search, error) {
+	searches := make([]func() (Search, error), 0, len(h))
+	for _, history := range h {
+		searches = append(searches, history.LastSearch)
+	}
+	return mostRecent(searches...)
+}
 
-	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return "", ErrHistoryIsEmpty
+// mostRecent runs every search and returns the latest result,
+// errors are only reported when no search found anything
+func mostRecent(searches ...func() (Search, error)) (Search, error) {
+	var (
+		last Search
+		errs []error
+	)
+	for _, search := range searches {
+		s, err := search()
+		if err != nil {
+			if !errors.Is(err, ErrHistoryIsEmpty) {
+				errs = append(errs, err)
+			}
+			continue
 		}
-		return "", err
+		if last.Query == "" || s.Time.After(last.Time) {
+			last = s
+		}
+	}
+
+	if last.Query != "" {
+		return last, nil
 	}
-	return value, nil
+	if len(errs) > 0 {
+		return Search{}, errors.Join(errs...)
+	}
+	return Search{}, ErrHistoryIsEmpty
 }
 
 type noopHistory struct{}
 
 func (h *noopHistory) Close() error                   { return nil }
 func (h *noopHistory) GetLastSearch() (string, error) { return "", nil }
+func (h *noopHistory) LastSearch() (Search, error)    { return Search{}, nil }
+
+// recentSearchURLs bounds how many matching rows are read before giving up
+const recentSearchURLs = 200
+
+// urlSearch returns the most recent result page of engines among the rows of query,
+// query selects a url and its visit time, has a %s placeholder for the url condition and takes a LIMIT
+func urlSearch(db *sql.DB, query string, engines []Engine, only engineFilter, visitTime func(int64) time.Time) (Search, error) {
+	var (
+		where []string
+		args  []any
+	)
+	for _, e := range engines {
+		if !only.allows(e.Name) {
+			continue
+		}
+		for _, pattern := range e.likePatterns() {
+			where = append(where, `url LIKE ? ESCAPE '\'`)
+			args = append(args, pattern)
+		}
+	}
+	if len(where) == 0 {
+		return Search{}, ErrHistoryIsEmpty
+	}
+
+	rows, err := db.Query(fmt.Sprintf(query, strings.Join(where, " OR ")), append(args, recentSearchURLs)...)
+	if err != nil {
+		return Search{}, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			u       string
+			visited int64
+		)
+		if err := rows.Scan(&u, &visited); err != nil {
+			return Search{}, err
+		}
+		if e, q, ok := matchEngine(engines, u); ok && only.allows(e.Name) {
+			return Search{Query: q, Engine: e.Name, Time: visitTime(visited)}, nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return Search{}, err
+	}
+	return Search{}, ErrHistoryIsEmpty
+}
 
-func openHistoryDB(browserName string, fullPath string) (History, error) {
-	path := fullPath
-	isChromium := IsChromiumBased(browserName)
+func openHistoryDB(opts Options) (History, error) {
+	engines, err := Engines(opts.Engines)
+	if err != nil {
+		return nil, err
+	}
 
-	if path == "" && isChromium {
-		path = fmt.Sprintf("%s/.config/%s/Default/History", os.Getenv("HOME"), browserName)
+	paths, err := historyPaths(opts)
+	if err != nil {
+		return nil, err
 	}
-	if path == "" && !isChromium {
-		return nil, errors.New("firefox-based browsers do not support auto-detecting history file")
+
+	// with several profiles one that cannot be read is skipped
+	var (
+		histories []History
+		errs      []error
+	)
+	for _, path := range paths {
+		h, err := openHistory(opts, path, engines)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		histories = append(histories, h)
+	}
+
+	switch len(histories) {
+	case 0:
+		return nil, errors.Join(errs...)
+	case 1:
+		return histories[0], nil
+	default:
+		return multiHistory(histories), nil
+	}
+}
+
+// historyPaths returns the history file or profile directory of every selected profile
+func historyPaths(opts Options) ([]string, error) {
+	switch {
+	case opts.HistoryPath != "":
+		return []string{opts.HistoryPath}, nil
+	case IsChromiumBased(opts.Browser):
+		paths, err := chromiumProfiles(opts.Browser, opts.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("detect %s profile: %w", opts.Browser, err)
+		}
+		return paths, nil
+	case IsFirefoxBased(opts.Browser):
+		paths, err := firefoxProfiles(opts.Browser, opts.Profile)
+		if err != nil {
+			return nil, fmt.Errorf("detect %s profile: %w", opts.Browser, err)
+		}
+		return paths, nil
+	default:
+		return nil, fmt.Errorf("cannot detect the history file of %s, set --history-file", opts.Browser)
 	}
+}
 
-	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?immutable=1&mode=ro", path))
+// probeRoots calls pick with every install root of a browser relative to home, native installs are listed
+// before flatpak and snap ones. The profiles of the first root pick accepts are returned,
+// roots for which pick reports os.ErrNotExist are not installed and skipped silently
+func probeRoots(what string, roots []string, pick func(root string) ([]string, error)) ([]string, error) {
+	home, err := os.UserHomeDir()
 	if err != nil {
 		return nil, err
 	}
 
-	if isChromium {
-		return &chromiumHistory{db: db}, nil
+	var errs []error
+	for _, root := range roots {
+		paths, err := pick(filepath.Join(home, root))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		return paths, nil
+	}
+
+	if len(errs) > 0 {
+		return nil, errors.Join(errs...)
+	}
+	return nil, fmt.Errorf("no %s found in %s", what, strings.Join(roots, ", "))
+}
+
+func openHistory(opts Options, path string, engines []Engine) (History, error) {
+	if !IsChromiumBased(opts.Browser) {
+		return openFirefoxHistory(path, engines, opts.OnlyEngines)
 	}
-	return &firefoxHistory{db: db}, nil
+
+	db, err := openDB(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return &chromiumHistory{
+		db:         db,
+		engines:    engines,
+		shortNames: loadShortNames(filepath.Join(filepath.Dir(path), webDataFile)),
+		only:       opts.OnlyEngines,
+	}, nil
+}
+
+// openDB opens a browser database read-only without taking the lock of the running browser
+func openDB(path string) (*sql.DB, error) {
+	return sql.Open("sqlite", fmt.Sprintf("file:%s?immutable=1&mode=ro", path))
 }
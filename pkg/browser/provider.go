@@ -1,9 +1,23 @@
 package browser
 
-func NewHistoryProvider(name string, customPath string) (History, error) {
-	if name == "noop" {
+// Options selects the browser history to read
+type Options struct {
+	// Browser is one of AvailableBrowsers or NoopBrowser
+	Browser string
+	// HistoryPath overrides the detected history file
+	HistoryPath string
+	// Profile is the profile name or directory to read, the default profile when empty and every one for AllProfiles
+	Profile string
+	// Engines are extra search engines as name to result url with a {query} parameter
+	Engines map[string]string
+	// OnlyEngines restricts searches to these engine names, empty allows any
+	OnlyEngines []string
+}
+
+func NewHistoryProvider(opts Options) (History, error) {
+	if opts.Browser == NoopBrowser {
 		return &noopHistory{}, nil
 	}
 
-	return openHistoryDB(name, customPath)
+	return openHistoryDB(opts)
 }